@@ -0,0 +1,105 @@
+// codec-bench 比較各種消息編碼的大小與編解碼成本，使用具代表性的遊戲消息
+//
+//	go run ./cmd/codec-bench
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"text/tabwriter"
+
+	"github.com/rejxcy/colorgame/backend/controllers/game"
+)
+
+// 具代表性的伺服器消息：滿房的玩家列表、單一玩家的遊戲狀態與分數變化
+func sampleMessages() []game.Message {
+	players := make([]game.PlayerListEntry, 0, game.MaxPlayers)
+	for i := 0; i < game.MaxPlayers; i++ {
+		total := i * 37
+		players = append(players, game.PlayerListEntry{
+			ID:         fmt.Sprintf("3f6c2a8e-1b4d-4e9a-9c7f-%012d", i),
+			Name:       fmt.Sprintf("玩家%d", i+1),
+			IsReady:    true,
+			Progress:   i + 3,
+			WrongCount: i % 3,
+			Score:      100 - i*5,
+			Rank:       i + 1,
+			TotalScore: &total,
+		})
+	}
+
+	return []game.Message{
+		{Seq: 1024, Type: game.MsgTypePlayerList, Payload: players},
+		{Type: game.MsgTypeGameState, Payload: game.GameStatePayload{
+			Name: "玩家1",
+			GameStatus: game.GameStatus{
+				Mode:         game.GameModeClassic,
+				Quiz:         "red",
+				QuizLanguage: game.LocaleEn,
+				DisplayColor: "blue",
+				DisplayHex:   "#1E88E5",
+				Progress:     4,
+				WrongCount:   1,
+				TotalQuiz:    10,
+			},
+		}},
+		{Seq: 1025, Type: game.MsgTypeScoreEvent, Payload: game.ScoreEvent{
+			PlayerID:   "3f6c2a8e-1b4d-4e9a-9c7f-000000000000",
+			Name:       "玩家1",
+			Delta:      10,
+			Reason:     game.ScoreReasonCorrect,
+			Streak:     3,
+			Multiplier: 1,
+			Score:      40,
+		}},
+	}
+}
+
+// 客戶端最常發送的消息
+var sampleAnswer = map[string][]byte{
+	game.SubprotocolJSON: []byte(`{"id":"a1","type":"answer","payload":"red"}`),
+	// {"id":"a1","type":"answer","payload":"red"}
+	game.SubprotocolMsgpack: {0x83, 0xa2, 'i', 'd', 0xa2, 'a', '1', 0xa4, 't', 'y', 'p', 'e', 0xa6, 'a', 'n', 's', 'w', 'e', 'r',
+		0xa7, 'p', 'a', 'y', 'l', 'o', 'a', 'd', 0xa3, 'r', 'e', 'd'},
+}
+
+func main() {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "編碼\t消息\t大小 (bytes)\tns/op\tB/op\tallocs/op\t")
+
+	for _, name := range game.Subprotocols() {
+		codec := game.CodecFor(name)
+		for _, msg := range sampleMessages() {
+			msg := msg
+			data, err := codec.Encode(msg)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s 編碼 %s 失敗: %v\n", name, msg.Type, err)
+				os.Exit(1)
+			}
+			result := testing.Benchmark(func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					codec.Encode(msg)
+				}
+			})
+			fmt.Fprintf(w, "%s\tencode %s\t%d\t%d\t%d\t%d\t\n",
+				name, msg.Type, len(data), result.NsPerOp(), result.AllocedBytesPerOp(), result.AllocsPerOp())
+		}
+
+		data := sampleAnswer[name]
+		if _, err := codec.Decode(data); err != nil {
+			fmt.Fprintf(os.Stderr, "%s 解碼失敗: %v\n", name, err)
+			os.Exit(1)
+		}
+		result := testing.Benchmark(func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				codec.Decode(data)
+			}
+		})
+		fmt.Fprintf(w, "%s\tdecode %s\t%d\t%d\t%d\t%d\t\n",
+			name, game.MsgTypeAnswer, len(data), result.NsPerOp(), result.AllocedBytesPerOp(), result.AllocsPerOp())
+	}
+	w.Flush()
+}
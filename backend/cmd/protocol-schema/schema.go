@@ -0,0 +1,34 @@
+// protocol-schema 將 WebSocket 協定的 JSON Schema 輸出到檔案，供前端產生型別與驗證消息
+//
+//	go run ./cmd/protocol-schema -o ../frontend/src/types/protocol.schema.json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rejxcy/colorgame/backend/controllers/game"
+)
+
+func main() {
+	output := flag.String("o", "", "輸出檔案路徑，未指定時輸出到標準輸出")
+	flag.Parse()
+
+	data, err := json.MarshalIndent(game.ProtocolSchema(), "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "產生 JSON Schema 失敗: %v\n", err)
+		os.Exit(1)
+	}
+	data = append(data, '\n')
+
+	if *output == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "寫入 %s 失敗: %v\n", *output, err)
+		os.Exit(1)
+	}
+}
@@ -6,7 +6,8 @@ import (
 
 // Context 提供控制器所需的共享資源和功能
 type Context struct {
-	GameRooms sync.Map
+	GameRooms   sync.Map
+	Tournaments sync.Map
 }
 
 // NewContext 創建新的 Context
@@ -0,0 +1,202 @@
+package game
+
+import (
+	"time"
+
+	"github.com/rejxcy/logger"
+)
+
+// 自動主持相關常數
+const (
+	DefaultAutoHostMinPlayers   = 2
+	DefaultAutoHostReadyPercent = 100
+	DefaultAutoHostCountdown    = 5
+	DefaultAutoHostIntermission = 10
+	MaxAutoHostCountdown        = 60
+	MaxAutoHostIntermission     = 300
+)
+
+// 自動主持的狀態
+const (
+	AutoHostStateWaiting      = "waiting"
+	AutoHostStateCountdown    = "countdown"
+	AutoHostStateIntermission = "intermission"
+)
+
+// 自動主持規則：玩家人數與準備比例達標後倒數開始，結算後休息一段時間再自動進入下一回合或重置
+type AutoHostConfig struct {
+	MinPlayers          int `json:"minPlayers"`
+	ReadyPercent        int `json:"readyPercent"`
+	CountdownSeconds    int `json:"countdownSeconds"`
+	IntermissionSeconds int `json:"intermissionSeconds"`
+}
+
+// 自動主持狀態，每次玩家加入、離開或改變準備狀態時廣播
+type AutoHostStatus struct {
+	State      string     `json:"state"`
+	EndsAt     *time.Time `json:"endsAt,omitempty"`
+	Players    int        `json:"players"`
+	Ready      int        `json:"ready"`
+	MinPlayers int        `json:"minPlayers"`
+}
+
+// DefaultAutoHostConfig 返回預設的自動主持規則
+func DefaultAutoHostConfig() AutoHostConfig {
+	return AutoHostConfig{
+		MinPlayers:          DefaultAutoHostMinPlayers,
+		ReadyPercent:        DefaultAutoHostReadyPercent,
+		CountdownSeconds:    DefaultAutoHostCountdown,
+		IntermissionSeconds: DefaultAutoHostIntermission,
+	}
+}
+
+// Validate 檢查自動主持規則是否在允許範圍內
+func (c AutoHostConfig) Validate() error {
+	if c.MinPlayers < MinPlayers || c.MinPlayers > MaxPlayers {
+		return NewError(ErrCodeInvalidSettings)
+	}
+	if c.ReadyPercent < 0 || c.ReadyPercent > 100 {
+		return NewError(ErrCodeInvalidSettings)
+	}
+	if c.CountdownSeconds < 0 || c.CountdownSeconds > MaxAutoHostCountdown {
+		return NewError(ErrCodeInvalidSettings)
+	}
+	if c.IntermissionSeconds < 1 || c.IntermissionSeconds > MaxAutoHostIntermission {
+		return NewError(ErrCodeInvalidSettings)
+	}
+	return nil
+}
+
+// NewAutoHostRoom 創建由伺服器擔任房主的房間，會持續循環進行比賽
+func NewAutoHostRoom(id string, settings RoomSettings, config AutoHostConfig) (*Room, error) {
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	room := NewRoom(id)
+	room.Settings = settings
+	room.ServerHosted = true
+	room.AutoHost = &config
+	return room, nil
+}
+
+// 人數與準備比例是否達到開始條件，呼叫時需持有 r.mu
+func (r *Room) autoHostStatusLocked() (AutoHostStatus, bool) {
+	status := AutoHostStatus{
+		State:      r.autoHostState,
+		MinPlayers: r.AutoHost.MinPlayers,
+	}
+	status.Ready, status.Players = r.readyCountsLocked()
+	if status.State == "" {
+		status.State = AutoHostStateWaiting
+	}
+	if !r.autoHostEndsAt.IsZero() {
+		endsAt := r.autoHostEndsAt
+		status.EndsAt = &endsAt
+	}
+	met := status.Players >= r.AutoHost.MinPlayers &&
+		status.Ready*100 >= r.AutoHost.ReadyPercent*status.Players
+	return status, met
+}
+
+// checkAutoHost 在玩家加入、離開或改變準備狀態後檢查是否開始或取消倒數，並廣播目前狀態
+func (r *Room) checkAutoHost() {
+	if r.AutoHost == nil {
+		return
+	}
+
+	r.mu.Lock()
+	if r.Status != RoomStatusWaiting {
+		r.mu.Unlock()
+		return
+	}
+	_, met := r.autoHostStatusLocked()
+	switch {
+	case met && r.autoHostState != AutoHostStateCountdown:
+		countdown := time.Duration(r.AutoHost.CountdownSeconds) * time.Second
+		r.setAutoHostTimerLocked(AutoHostStateCountdown, countdown, r.autoHostStart)
+		logger.Output.Info("房間 %s 自動開始倒數 %d 秒", r.ID, r.AutoHost.CountdownSeconds)
+	case !met && r.autoHostState == AutoHostStateCountdown:
+		r.stopAutoHostTimerLocked()
+		logger.Output.Info("房間 %s 條件不足，取消倒數", r.ID)
+	}
+	status, _ := r.autoHostStatusLocked()
+	r.mu.Unlock()
+
+	r.Broadcast(Message{Type: MsgTypeAutoHost, Payload: status})
+}
+
+// 倒數結束時開始遊戲
+func (r *Room) autoHostStart() {
+	r.mu.Lock()
+	if r.autoHostState != AutoHostStateCountdown {
+		r.mu.Unlock()
+		return
+	}
+	r.stopAutoHostTimerLocked()
+	r.mu.Unlock()
+
+	if err := r.StartGame(); err != nil {
+		logger.Output.Error("房間 %s 自動開始失敗: %v", r.ID, err)
+		r.checkAutoHost()
+	}
+}
+
+// 每回合結算後進入休息，休息結束時自動進入下一回合或重置比賽
+func (r *Room) scheduleAutoHostNext() {
+	if r.AutoHost == nil {
+		return
+	}
+
+	r.mu.Lock()
+	intermission := time.Duration(r.AutoHost.IntermissionSeconds) * time.Second
+	r.setAutoHostTimerLocked(AutoHostStateIntermission, intermission, r.autoHostNext)
+	status, _ := r.autoHostStatusLocked()
+	r.mu.Unlock()
+
+	r.Broadcast(Message{Type: MsgTypeAutoHost, Payload: status})
+}
+
+// 休息結束：比賽尚有回合時直接開始下一回合，否則重置房間並等待下一場
+func (r *Room) autoHostNext() {
+	r.mu.Lock()
+	if r.autoHostState != AutoHostStateIntermission {
+		r.mu.Unlock()
+		return
+	}
+	r.stopAutoHostTimerLocked()
+	status := r.Status
+	r.mu.Unlock()
+
+	if status == RoomStatusIntermission {
+		if err := r.StartGame(); err != nil {
+			logger.Output.Error("房間 %s 自動開始下一回合失敗: %v", r.ID, err)
+		}
+		return
+	}
+	if err := r.GameReset(); err != nil {
+		logger.Output.Error("房間 %s 自動重置失敗: %v", r.ID, err)
+		return
+	}
+	r.checkReady()
+}
+
+// 設定自動主持的計時器，呼叫時需持有 r.mu
+func (r *Room) setAutoHostTimerLocked(state string, d time.Duration, f func()) {
+	r.stopAutoHostTimerLocked()
+	r.autoHostState = state
+	r.autoHostEndsAt = time.Now().Add(d)
+	r.autoHostTimer = time.AfterFunc(d, f)
+}
+
+// 停止自動主持的計時器，呼叫時需持有 r.mu
+func (r *Room) stopAutoHostTimerLocked() {
+	if r.autoHostTimer != nil {
+		r.autoHostTimer.Stop()
+		r.autoHostTimer = nil
+	}
+	r.autoHostState = ""
+	r.autoHostEndsAt = time.Time{}
+}
@@ -0,0 +1,104 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAutoHostConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*AutoHostConfig)
+		wantErr bool
+	}{
+		{name: "default", modify: func(*AutoHostConfig) {}},
+		{name: "no min players", modify: func(c *AutoHostConfig) { c.MinPlayers = 0 }, wantErr: true},
+		{name: "percent over 100", modify: func(c *AutoHostConfig) { c.ReadyPercent = 101 }, wantErr: true},
+		{name: "countdown too long", modify: func(c *AutoHostConfig) { c.CountdownSeconds = MaxAutoHostCountdown + 1 }, wantErr: true},
+		{name: "no intermission", modify: func(c *AutoHostConfig) { c.IntermissionSeconds = 0 }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultAutoHostConfig()
+			tt.modify(&config)
+			if err := config.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// 目前的自動主持狀態
+func autoHostState(room *Room) string {
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	return room.autoHostState
+}
+
+func TestAutoHostCountdown(t *testing.T) {
+	config := DefaultAutoHostConfig()
+	config.CountdownSeconds = MaxAutoHostCountdown
+	room, err := NewAutoHostRoom("auto", DefaultRoomSettings(), config)
+	if err != nil {
+		t.Fatalf("new room: %v", err)
+	}
+	defer room.Close()
+
+	alice, _ := joinTestPlayer(t, room, "alice")
+	bob, _ := joinTestPlayer(t, room, "bob")
+	deliver(t, room, alice, `{"type":"ready","payload":true}`)
+	if state := autoHostState(room); state != "" {
+		t.Fatalf("state = %q with one of two ready, want waiting", state)
+	}
+
+	// 全部準備後開始倒數，有人取消準備時停止倒數
+	deliver(t, room, bob, `{"type":"ready","payload":true}`)
+	if state := autoHostState(room); state != AutoHostStateCountdown {
+		t.Fatalf("state = %q with everyone ready, want countdown", state)
+	}
+	deliver(t, room, bob, `{"type":"ready","payload":false}`)
+	if state := autoHostState(room); state != "" {
+		t.Fatalf("state = %q after bob unready, want countdown cancelled", state)
+	}
+}
+
+func TestAutoHostStartsAndCycles(t *testing.T) {
+	config := DefaultAutoHostConfig()
+	config.CountdownSeconds = 0
+	config.ReadyPercent = 0
+	room, err := NewAutoHostRoom("auto", DefaultRoomSettings(), config)
+	if err != nil {
+		t.Fatalf("new room: %v", err)
+	}
+	defer room.Close()
+
+	// 人數達標且不需準備時，倒數結束即由伺服器開始遊戲
+	joinTestPlayer(t, room, "alice")
+	joinTestPlayer(t, room, "bob")
+	room.checkReady()
+	deadline := time.Now().Add(time.Second)
+	for {
+		room.mu.Lock()
+		status := room.Status
+		room.mu.Unlock()
+		if status == RoomStatusPlaying {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("status = %s, want the room started automatically", status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// 比賽結束後進入休息，休息結束重置房間等待下一場
+	room.endGame()
+	if state := autoHostState(room); state != AutoHostStateIntermission {
+		t.Fatalf("state = %q after the match, want intermission", state)
+	}
+	room.autoHostNext()
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	if room.Status != RoomStatusWaiting && room.Status != RoomStatusPlaying {
+		t.Fatalf("status = %s after intermission, want the room reset", room.Status)
+	}
+}
@@ -0,0 +1,222 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/rejxcy/logger"
+)
+
+// 機器人的難度
+const (
+	BotDifficultyEasy   = "easy"
+	BotDifficultyMedium = "medium"
+	BotDifficultyHard   = "hard"
+)
+
+// 機器人作答的最短反應時間
+const minBotReaction = 200 * time.Millisecond
+
+// BotSkill 機器人的作答能力：反應時間呈常態分布，並以固定機率答錯
+type BotSkill struct {
+	// 平均反應時間與標準差
+	Reaction time.Duration
+	Jitter   time.Duration
+	// 答錯的機率（0 至 1）
+	ErrorRate float64
+}
+
+// 各難度對應的作答能力
+var botSkills = map[string]BotSkill{
+	BotDifficultyEasy:   {Reaction: 1800 * time.Millisecond, Jitter: 600 * time.Millisecond, ErrorRate: 0.25},
+	BotDifficultyMedium: {Reaction: 1100 * time.Millisecond, Jitter: 350 * time.Millisecond, ErrorRate: 0.1},
+	BotDifficultyHard:   {Reaction: 650 * time.Millisecond, Jitter: 150 * time.Millisecond, ErrorRate: 0.03},
+}
+
+// AddBotsPayload 房主加入機器人的請求
+type AddBotsPayload struct {
+	Count      int    `json:"count"`
+	Difficulty string `json:"difficulty"`
+	// 是否不列入排名與比賽結算
+	Unranked bool `json:"unranked,omitempty"`
+}
+
+// 伺服器端的機器人：以程序內連線接收房間消息，收到遊戲狀態後依作答能力延遲作答
+type bot struct {
+	player *Player
+	conn   *MemoryConn
+	room   *Room
+	skill  BotSkill
+	rng    *rand.Rand
+	// 尚未送出的答案，收到新的消息時重新排程
+	pending *time.Timer
+}
+
+// AddBots 加入指定數量的機器人，機器人加入後即為準備狀態
+func (r *Room) AddBots(payload AddBotsPayload) error {
+	skill, ok := botSkills[payload.Difficulty]
+	if !ok || payload.Count < 1 {
+		return NewError(ErrCodeInvalidBotConfig)
+	}
+
+	r.mu.Lock()
+	if len(r.Players)+payload.Count > MaxPlayers {
+		r.mu.Unlock()
+		return NewError(ErrCodeRoomFull)
+	}
+	names := make([]string, payload.Count)
+	for i := range names {
+		r.botCount++
+		names[i] = fmt.Sprintf("Bot %d", r.botCount)
+	}
+	r.mu.Unlock()
+
+	for _, name := range names {
+		conn := NewMemoryConn(name)
+		player := NewPlayer(conn, name, false)
+		player.IsBot = true
+		player.IsReady = true
+		player.unranked = payload.Unranked
+		if err := r.AddPlayer(player); err != nil {
+			return err
+		}
+		b := &bot{
+			player: player,
+			conn:   conn,
+			room:   r,
+			skill:  skill,
+			rng:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		}
+		go b.run()
+		r.SendLateJoinState(player)
+		logger.Output.Info("Bot %s (%s) joined room %s", name, payload.Difficulty, r.ID)
+	}
+
+	r.BroadcastPlayerList()
+	r.checkReady()
+	return nil
+}
+
+// RemoveBots 移出房間內所有機器人
+func (r *Room) RemoveBots() {
+	r.mu.Lock()
+	bots := make([]*Player, 0)
+	for id, p := range r.Players {
+		if p.IsBot {
+			delete(r.Players, id)
+			bots = append(bots, p)
+		}
+	}
+	r.mu.Unlock()
+	if len(bots) == 0 {
+		return
+	}
+
+	for _, p := range bots {
+		p.Close()
+	}
+	logger.Output.Info("Removed %d bots from room %s", len(bots), r.ID)
+	r.BroadcastPlayerList()
+	r.checkReady()
+}
+
+// 房間內是否還有真人玩家（包含房主）
+func (r *Room) hasHumans() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.Players {
+		if !p.IsBot {
+			return true
+		}
+	}
+	return false
+}
+
+// 依序處理房間消息直到連線關閉：遊戲重置後重新準備，收到遊戲狀態後排程作答
+func (b *bot) run() {
+	defer b.cancel()
+	for {
+		data, err := b.conn.Receive()
+		if err != nil {
+			return
+		}
+		var msg struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+
+		switch msg.Type {
+		case MsgTypeGameState:
+			b.schedule()
+		case MsgTypeGameReset:
+			b.cancel()
+			ready := ReadyPayload(true)
+			if err := b.player.HandleMessage(MsgTypeReady, &ready, b.room); err != nil {
+				logger.Output.Error("Bot %s failed to get ready: %v", b.player.Name, err)
+			}
+		case MsgTypeGameEnd, MsgTypeRoundEnd, MsgTypeMatchEnd:
+			b.cancel()
+		}
+	}
+}
+
+// 依反應時間排程回答目前的題目
+func (b *bot) schedule() {
+	b.cancel()
+	b.room.mu.Lock()
+	game := b.player.Game
+	if game == nil || game.IsFinished || b.player.IsSpectator {
+		b.room.mu.Unlock()
+		return
+	}
+	progress := game.Progress
+	b.room.mu.Unlock()
+
+	delay := b.skill.Reaction + time.Duration(b.rng.NormFloat64()*float64(b.skill.Jitter))
+	if delay < minBotReaction {
+		delay = minBotReaction
+	}
+	wrong := b.rng.Float64() < b.skill.ErrorRate
+	pick := b.rng.Int()
+	b.pending = time.AfterFunc(delay, func() { b.answer(game, progress, wrong, pick) })
+}
+
+func (b *bot) cancel() {
+	if b.pending != nil {
+		b.pending.Stop()
+		b.pending = nil
+	}
+}
+
+// 回答排程時的題目；遊戲已重新開始或題目已變更時放棄作答
+// 答錯時優先選擇題目的顯示顏色，模擬真人受到顏色干擾的錯誤
+func (b *bot) answer(game *Game, progress int, wrong bool, pick int) {
+	b.room.mu.Lock()
+	if b.player.Game != game || game.IsFinished || game.Progress != progress {
+		b.room.mu.Unlock()
+		return
+	}
+	answer := game.QuizList[progress]
+	if wrong {
+		if display := game.ColorList[progress]; display != answer {
+			answer = display
+		} else if keys := game.palette.Keys(); len(keys) > 1 {
+			others := make([]string, 0, len(keys)-1)
+			for _, key := range keys {
+				if key != answer {
+					others = append(others, key)
+				}
+			}
+			answer = others[pick%len(others)]
+		}
+	}
+	b.room.mu.Unlock()
+
+	if err := b.player.handleAnswer(answer, b.room); err != nil {
+		logger.Output.Error("Bot %s failed to answer: %v", b.player.Name, err)
+	}
+}
@@ -0,0 +1,209 @@
+package game
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// 以程序內連線建立房主並加入房間
+func joinTestHost(t *testing.T, room *Room) (*Player, *MemoryConn) {
+	t.Helper()
+	conn := NewMemoryConn("host")
+	host := NewPlayer(conn, "host", true)
+	if err := room.AddPlayer(host); err != nil {
+		t.Fatalf("add host: %v", err)
+	}
+	return host, conn
+}
+
+// 房間內的機器人玩家
+func roomBots(room *Room) []*Player {
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	bots := make([]*Player, 0)
+	for _, p := range room.Players {
+		if p.IsBot {
+			bots = append(bots, p)
+		}
+	}
+	return bots
+}
+
+func TestAddBotsRequests(t *testing.T) {
+	tests := []struct {
+		name    string
+		byHost  bool
+		msg     string
+		wantErr string
+	}{
+		{name: "host", byHost: true, msg: `{"count":2,"difficulty":"easy"}`},
+		{name: "not host", msg: `{"count":2,"difficulty":"easy"}`, wantErr: ErrCodeNotHost},
+		{name: "unknown difficulty", byHost: true, msg: `{"count":2,"difficulty":"expert"}`, wantErr: ErrCodeInvalidBotConfig},
+		{name: "no bots", byHost: true, msg: `{"count":0,"difficulty":"easy"}`, wantErr: ErrCodeInvalidBotConfig},
+		{name: "room full", byHost: true, msg: `{"count":10,"difficulty":"easy"}`, wantErr: ErrCodeRoomFull},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			room := NewRoom("bots")
+			defer room.RemoveBots()
+			host, hostConn := joinTestHost(t, room)
+			player, playerConn := joinTestPlayer(t, room, "alice")
+			sender, conn := player, playerConn
+			if tt.byHost {
+				sender, conn = host, hostConn
+			}
+			drainMessages(t, conn)
+
+			deliver(t, room, sender, `{"id":"b1","type":"add_bots","payload":`+tt.msg+`}`)
+			msgs := drainMessages(t, conn)
+			var reply receivedMessage
+			for _, msg := range msgs {
+				if msg.ID == "b1" {
+					reply = msg
+				}
+			}
+			if tt.wantErr == "" {
+				if reply.Type != MsgTypeAck || len(roomBots(room)) != 2 {
+					t.Fatalf("reply %s, %d bots; want ack and 2 bots", reply.Type, len(roomBots(room)))
+				}
+				return
+			}
+			var payload ErrorPayload
+			if reply.Type != MsgTypeError || json.Unmarshal(reply.Payload, &payload) != nil || payload.Code != tt.wantErr {
+				t.Fatalf("reply = %s %s, want error %s", reply.Type, reply.Payload, tt.wantErr)
+			}
+			if bots := roomBots(room); len(bots) != 0 {
+				t.Fatalf("%d bots joined after a rejected request", len(bots))
+			}
+		})
+	}
+}
+
+func TestBotsJoinReadyAndLeave(t *testing.T) {
+	room := NewRoom("bots")
+	host, hostConn := joinTestHost(t, room)
+	if err := room.AddBots(AddBotsPayload{Count: 2, Difficulty: BotDifficultyMedium, Unranked: true}); err != nil {
+		t.Fatalf("add bots: %v", err)
+	}
+
+	// 機器人加入後即為準備狀態，並在玩家列表中標示為機器人
+	bots := roomBots(room)
+	for _, bot := range bots {
+		if !bot.IsReady {
+			t.Fatalf("bot %s is not ready", bot.Name)
+		}
+	}
+	var entries []PlayerListEntry
+	for _, msg := range drainMessages(t, hostConn) {
+		if msg.Type == MsgTypePlayerList {
+			if err := json.Unmarshal(msg.Payload, &entries); err != nil {
+				t.Fatalf("decode player list: %v", err)
+			}
+		}
+	}
+	if len(entries) != 2 {
+		t.Fatalf("player list has %d entries, want the 2 bots", len(entries))
+	}
+	for _, entry := range entries {
+		// 不列入排名的機器人沒有名次
+		if !entry.IsBot || entry.Rank != 0 {
+			t.Fatalf("entry = %+v, want an unranked bot", entry)
+		}
+	}
+
+	deliver(t, room, host, `{"type":"remove_bots"}`)
+	if bots := roomBots(room); len(bots) != 0 {
+		t.Fatalf("%d bots left after remove_bots", len(bots))
+	}
+	for _, bot := range bots {
+		if _, err := bot.Conn.ReadMessage(); err == nil {
+			t.Fatalf("bot %s connection still open after removal", bot.Name)
+		}
+	}
+}
+
+func TestUnrankedBotsExcludedFromMatch(t *testing.T) {
+	room := NewRoom("bots")
+	defer room.RemoveBots()
+	room.Settings.Rounds = 2
+	alice, _ := joinTestPlayer(t, room, "alice")
+	if err := room.AddBots(AddBotsPayload{Count: 1, Difficulty: BotDifficultyHard, Unranked: true}); err != nil {
+		t.Fatalf("add bots: %v", err)
+	}
+	bot := roomBots(room)[0]
+
+	playRound(t, room, map[*Player]int{alice: 10, bot: 50})
+	room.mu.Lock()
+	standings := room.Match.Standings()
+	room.mu.Unlock()
+	if len(standings) != 1 || standings[0].ID != alice.ID {
+		t.Fatalf("standings = %+v, want only alice", standings)
+	}
+}
+
+func TestBotAnswer(t *testing.T) {
+	room := NewRoom("bots")
+	joinTestPlayer(t, room, "alice")
+	// 不啟動消息循環，直接呼叫作答以避免依賴反應時間
+	player := NewPlayer(NewMemoryConn("Bot 1"), "Bot 1", false)
+	player.IsBot = true
+	if err := room.AddPlayer(player); err != nil {
+		t.Fatalf("add bot: %v", err)
+	}
+	if err := room.ForceStart(); err != nil {
+		t.Fatalf("start game: %v", err)
+	}
+	b := &bot{player: player, room: room, skill: botSkills[BotDifficultyHard], rng: rand.New(rand.NewSource(1))}
+
+	room.mu.Lock()
+	game := player.Game
+	room.mu.Unlock()
+	b.answer(game, 0, false, 0)
+	room.mu.Lock()
+	progress, wrong := game.Progress, game.WrongCount
+	room.mu.Unlock()
+	if progress != 1 || wrong != 0 {
+		t.Fatalf("after a correct answer: progress %d wrong %d, want 1 and 0", progress, wrong)
+	}
+
+	// 題目已變更時放棄排程的答案
+	b.answer(game, 0, true, 0)
+	b.answer(game, 1, true, 3)
+	room.mu.Lock()
+	progress, wrong = game.Progress, game.WrongCount
+	room.mu.Unlock()
+	if progress != 1 || wrong != 1 {
+		t.Fatalf("after a stale and a wrong answer: progress %d wrong %d, want 1 and 1", progress, wrong)
+	}
+}
+
+func TestBotAnswersGameState(t *testing.T) {
+	room := NewRoom("bots")
+	defer room.RemoveBots()
+	joinTestPlayer(t, room, "alice")
+	if err := room.AddBots(AddBotsPayload{Count: 1, Difficulty: BotDifficultyHard}); err != nil {
+		t.Fatalf("add bots: %v", err)
+	}
+	bot := roomBots(room)[0]
+	if err := room.ForceStart(); err != nil {
+		t.Fatalf("start game: %v", err)
+	}
+
+	// 收到遊戲狀態後依反應時間自動作答
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		room.mu.Lock()
+		answered := bot.Game.Progress > 0 || bot.Game.WrongCount > 0
+		room.mu.Unlock()
+		if answered {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("bot did not answer after the game started")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
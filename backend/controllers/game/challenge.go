@@ -0,0 +1,246 @@
+package game
+
+import (
+	"crypto/rand"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/rejxcy/logger"
+)
+
+// 挑戰連結相關常數
+const (
+	ChallengeCodeLength = 6
+	ChallengeTTL        = 7 * 24 * time.Hour
+	shortCodeChars      = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+// 全域的挑戰連結儲存
+var GlobalChallengeStore = NewChallengeStore()
+
+// 單局的詳細成績，用於挑戰的逐題比較
+type ChallengeResult struct {
+	Name            string  `json:"name"`
+	Score           int     `json:"score"`
+	Progress        int     `json:"progress"`
+	WrongCount      int     `json:"wrongCount"`
+	Accuracy        float64 `json:"accuracy"`
+	DurationMs      int64   `json:"durationMs"`
+	QuestionTimesMs []int64 `json:"questionTimesMs"`
+}
+
+// Challenge 保存挑戰者的題目種子、模式設定與成績，讓其他玩家之後以相同題目挑戰
+type Challenge struct {
+	Code      string          `json:"code"`
+	Seed      int64           `json:"-"`
+	Settings  RoomSettings    `json:"settings"`
+	Result    ChallengeResult `json:"result"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// 逐題用時比較，差值為負表示比挑戰者快
+type QuestionComparison struct {
+	Index    int   `json:"index"`
+	YoursMs  int64 `json:"yoursMs"`
+	TheirsMs int64 `json:"theirsMs"`
+	DiffMs   int64 `json:"diffMs"`
+}
+
+// 與挑戰者的對戰比較結果
+type ChallengeComparison struct {
+	Code         string               `json:"code"`
+	Original     ChallengeResult      `json:"original"`
+	Yours        ChallengeResult      `json:"yours"`
+	ScoreDiff    int                  `json:"scoreDiff"`
+	AccuracyDiff float64              `json:"accuracyDiff"`
+	Questions    []QuestionComparison `json:"questions"`
+	Outcome      string               `json:"outcome"`
+}
+
+// 對戰結果
+const (
+	ChallengeOutcomeWin  = "win"
+	ChallengeOutcomeLose = "lose"
+	ChallengeOutcomeDraw = "draw"
+)
+
+// ChallengeStore 以挑戰碼保存挑戰，過期的挑戰會在建立新挑戰時清除
+type ChallengeStore struct {
+	challenges map[string]*Challenge
+	mu         sync.Mutex
+}
+
+// NewChallengeStore 創建新的挑戰儲存
+func NewChallengeStore() *ChallengeStore {
+	return &ChallengeStore{
+		challenges: make(map[string]*Challenge),
+	}
+}
+
+// Create 建立新的挑戰並產生不重複的挑戰碼
+func (s *ChallengeStore) Create(seed int64, settings RoomSettings, result ChallengeResult) (*Challenge, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for code, c := range s.challenges {
+		if now.Sub(c.CreatedAt) > ChallengeTTL {
+			delete(s.challenges, code)
+		}
+	}
+
+	for {
+		code, err := newShortCode(ChallengeCodeLength)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := s.challenges[code]; exists {
+			continue
+		}
+		challenge := &Challenge{
+			Code:      code,
+			Seed:      seed,
+			Settings:  settings,
+			Result:    result,
+			CreatedAt: now,
+		}
+		s.challenges[code] = challenge
+		return challenge, nil
+	}
+}
+
+// Get 依挑戰碼取得尚未過期的挑戰
+func (s *ChallengeStore) Get(code string) *Challenge {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.challenges[code]
+	if !ok || time.Since(c.CreatedAt) > ChallengeTTL {
+		return nil
+	}
+	return c
+}
+
+// 產生指定長度的隨機代碼（排除容易混淆的字元），用於挑戰碼與錦標賽代碼
+func newShortCode(length int) (string, error) {
+	code := make([]byte, length)
+	max := big.NewInt(int64(len(shortCodeChars)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = shortCodeChars[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// NewChallengeResult 由玩家目前的遊戲結果建立詳細成績
+func NewChallengeResult(p *Player) ChallengeResult {
+	accuracy := 0.0
+	if answered := p.Game.Progress + p.Game.WrongCount; answered > 0 {
+		accuracy = float64(p.Game.Progress) / float64(answered)
+	}
+	return ChallengeResult{
+		Name:            p.Name,
+		Score:           p.Score,
+		Progress:        p.Game.Progress,
+		WrongCount:      p.Game.WrongCount,
+		Accuracy:        accuracy,
+		DurationMs:      p.Game.Duration().Milliseconds(),
+		QuestionTimesMs: append([]int64(nil), p.Game.QuestionTimesMs...),
+	}
+}
+
+// Compare 比較挑戰者與本次成績
+func (c *Challenge) Compare(yours ChallengeResult) ChallengeComparison {
+	cmp := ChallengeComparison{
+		Code:         c.Code,
+		Original:     c.Result,
+		Yours:        yours,
+		ScoreDiff:    yours.Score - c.Result.Score,
+		AccuracyDiff: yours.Accuracy - c.Result.Accuracy,
+		Questions:    make([]QuestionComparison, 0, len(yours.QuestionTimesMs)),
+	}
+
+	for i, ms := range yours.QuestionTimesMs {
+		if i >= len(c.Result.QuestionTimesMs) {
+			break
+		}
+		theirs := c.Result.QuestionTimesMs[i]
+		cmp.Questions = append(cmp.Questions, QuestionComparison{
+			Index:    i,
+			YoursMs:  ms,
+			TheirsMs: theirs,
+			DiffMs:   ms - theirs,
+		})
+	}
+
+	yoursRecord := GameRecord{Score: yours.Score, WrongCount: yours.WrongCount, DurationMs: yours.DurationMs}
+	theirsRecord := GameRecord{Score: c.Result.Score, WrongCount: c.Result.WrongCount, DurationMs: c.Result.DurationMs}
+	switch {
+	case yoursRecord.Better(theirsRecord):
+		cmp.Outcome = ChallengeOutcomeWin
+	case theirsRecord.Better(yoursRecord):
+		cmp.Outcome = ChallengeOutcomeLose
+	default:
+		cmp.Outcome = ChallengeOutcomeDraw
+	}
+	return cmp
+}
+
+// NewChallengeRoom 以挑戰的種子與設定創建單人房間，可指定不同的題目語言
+func NewChallengeRoom(challenge *Challenge, language string) (*Room, error) {
+	settings := challenge.Settings
+	if language != "" {
+		settings.QuizLanguage = language
+		settings.SecondaryLanguage = ""
+	}
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+	room := NewSoloRoom(settings)
+	room.Seed = challenge.Seed
+	room.Challenge = challenge
+	return room, nil
+}
+
+// CreateChallenge 以玩家剛完成的遊戲建立挑戰
+func (r *Room) CreateChallenge(p *Player) (*Challenge, error) {
+	r.mu.Lock()
+	if p.Game == nil || !p.Game.IsFinished || p.Game.StartedAt.IsZero() {
+		r.mu.Unlock()
+		return nil, NewError(ErrCodeGameNotFinished)
+	}
+	seed := p.Game.Seed
+	settings := r.Settings
+	result := NewChallengeResult(p)
+	r.mu.Unlock()
+
+	// 挑戰固定為單局
+	settings.Rounds = 1
+	return GlobalChallengeStore.Create(seed, settings, result)
+}
+
+// 挑戰結束時將比較結果發送給玩家
+func (r *Room) reportChallengeResults() {
+	r.mu.Lock()
+	challenge := r.Challenge
+	results := make(map[*Player]ChallengeResult, len(r.Players))
+	for _, p := range r.Players {
+		if !p.IsHost {
+			results[p] = NewChallengeResult(p)
+		}
+	}
+	r.mu.Unlock()
+
+	for p, result := range results {
+		msg := Message{
+			Type:    MsgTypeChallengeResult,
+			Payload: challenge.Compare(result),
+		}
+		if err := p.Send(msg); err != nil {
+			logger.Output.Error("發送挑戰結果給 %s 失敗: %v", p.Name, err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestChallengeCompare(t *testing.T) {
+	original := ChallengeResult{Score: 50, WrongCount: 1, DurationMs: 9000, QuestionTimesMs: []int64{1000, 2000, 3000}}
+	challenge := &Challenge{Code: "ABC123", Result: original}
+	tests := []struct {
+		name  string
+		yours ChallengeResult
+		want  string
+	}{
+		{name: "higher score wins", yours: ChallengeResult{Score: 60, WrongCount: 3, DurationMs: 20000}, want: ChallengeOutcomeWin},
+		{name: "fewer mistakes win a tie", yours: ChallengeResult{Score: 50, WrongCount: 0, DurationMs: 20000}, want: ChallengeOutcomeWin},
+		{name: "slower loses a tie", yours: ChallengeResult{Score: 50, WrongCount: 1, DurationMs: 10000}, want: ChallengeOutcomeLose},
+		{name: "identical draws", yours: ChallengeResult{Score: 50, WrongCount: 1, DurationMs: 9000}, want: ChallengeOutcomeDraw},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := challenge.Compare(tt.yours).Outcome; got != tt.want {
+				t.Fatalf("outcome = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// 逐題比較只涵蓋雙方都答到的題目
+	cmp := challenge.Compare(ChallengeResult{Score: 40, QuestionTimesMs: []int64{800, 2500}})
+	want := []QuestionComparison{
+		{Index: 0, YoursMs: 800, TheirsMs: 1000, DiffMs: -200},
+		{Index: 1, YoursMs: 2500, TheirsMs: 2000, DiffMs: 500},
+	}
+	if !reflect.DeepEqual(cmp.Questions, want) || cmp.ScoreDiff != -10 {
+		t.Fatalf("comparison = %+v, want %+v with score diff -10", cmp.Questions, want)
+	}
+}
+
+func TestChallengeReplaysSameQuizzes(t *testing.T) {
+	settings, err := SoloSettings("classic", "3", "", "")
+	if err != nil {
+		t.Fatalf("settings: %v", err)
+	}
+	room := NewSoloRoom(settings)
+	player, _ := joinTestPlayer(t, room, "alice")
+	if _, err := room.CreateChallenge(player); !errors.Is(err, NewError(ErrCodeGameNotFinished)) {
+		t.Fatalf("challenge before playing: err = %v, want %s", err, ErrCodeGameNotFinished)
+	}
+	if err := room.StartGame(); err != nil {
+		t.Fatalf("start game: %v", err)
+	}
+	for i := 0; i < settings.QuizCount; i++ {
+		deliver(t, room, player, `{"type":"answer","payload":"`+currentAnswer(room, player)+`"}`)
+	}
+	challenge, err := room.CreateChallenge(player)
+	if err != nil {
+		t.Fatalf("create challenge: %v", err)
+	}
+	if GlobalChallengeStore.Get(challenge.Code) != challenge {
+		t.Fatalf("challenge %s not stored", challenge.Code)
+	}
+
+	// 接受挑戰的玩家拿到相同的題目，結束後收到比較結果
+	challengeRoom, err := NewChallengeRoom(challenge, LocaleZhTW)
+	if err != nil {
+		t.Fatalf("challenge room: %v", err)
+	}
+	rival, conn := joinTestPlayer(t, challengeRoom, "bob")
+	if err := challengeRoom.StartGame(); err != nil {
+		t.Fatalf("start challenge: %v", err)
+	}
+	if !reflect.DeepEqual(rival.Game.QuizList, player.Game.QuizList) || !reflect.DeepEqual(rival.Game.ColorList, player.Game.ColorList) {
+		t.Fatal("challenge room generated different quizzes")
+	}
+	for i := 0; i < settings.QuizCount; i++ {
+		deliver(t, challengeRoom, rival, `{"type":"answer","payload":"`+currentAnswer(challengeRoom, rival)+`"}`)
+	}
+	for _, msg := range drainMessages(t, conn) {
+		if msg.Type != MsgTypeChallengeResult {
+			continue
+		}
+		var cmp ChallengeComparison
+		if err := json.Unmarshal(msg.Payload, &cmp); err != nil {
+			t.Fatalf("decode challenge result: %v", err)
+		}
+		if cmp.Code != challenge.Code || cmp.Original.Name != "alice" || cmp.Yours.Name != "bob" {
+			t.Fatalf("challenge result = %+v, want bob compared with alice", cmp)
+		}
+		return
+	}
+	t.Fatal("no challenge_result after finishing the challenge")
+}
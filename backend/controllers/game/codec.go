@@ -0,0 +1,112 @@
+package game
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/gorilla/websocket"
+	"github.com/ugorji/go/codec"
+)
+
+// WebSocket 子協定名稱，客戶端以 Sec-WebSocket-Protocol 選擇消息編碼，未指定時使用 JSON
+const (
+	SubprotocolJSON    = "colorgame.json"
+	SubprotocolMsgpack = "colorgame.msgpack"
+)
+
+// Codec 消息的編碼方式，決定 WebSocket 幀的類型與內容格式
+type Codec interface {
+	// Name 對應的 WebSocket 子協定名稱
+	Name() string
+	// FrameType WebSocket 幀類型（websocket.TextMessage 或 websocket.BinaryMessage）
+	FrameType() int
+	// Encode 編碼伺服器發送的消息
+	Encode(msg Message) ([]byte, error)
+	// Decode 解碼客戶端消息的外層，負載轉為 JSON 交由消息登記表嚴格解碼
+	Decode(data []byte) (Envelope, error)
+}
+
+// 伺服器支援的子協定，依偏好順序排列
+var codecs = []Codec{msgpackCodec{}, jsonCodec{}}
+
+// Subprotocols 返回伺服器支援的子協定名稱，供 websocket.Upgrader 協商
+func Subprotocols() []string {
+	names := make([]string, 0, len(codecs))
+	for _, c := range codecs {
+		names = append(names, c.Name())
+	}
+	return names
+}
+
+// CodecFor 依協商後的子協定返回編碼方式，未協商時使用 JSON
+func CodecFor(subprotocol string) Codec {
+	for _, c := range codecs {
+		if c.Name() == subprotocol {
+			return c
+		}
+	}
+	return jsonCodec{}
+}
+
+// JSON 編碼，以文字幀傳送
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string   { return SubprotocolJSON }
+func (jsonCodec) FrameType() int { return websocket.TextMessage }
+
+func (jsonCodec) Encode(msg Message) ([]byte, error) {
+	return json.Marshal(msg)
+}
+
+func (jsonCodec) Decode(data []byte) (Envelope, error) {
+	return parseEnvelope(data)
+}
+
+// MessagePack 編碼，以二進位幀傳送；欄位名稱與是否省略依照 json 標籤，與 JSON 格式的結構相同
+type msgpackCodec struct{}
+
+var msgpackHandle = func() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{}
+	h.TypeInfos = codec.NewTypeInfos([]string{"json"})
+	h.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	h.RawToString = true
+	h.WriteExt = true
+	h.ErrorIfNoField = true
+	return h
+}()
+
+// 客戶端消息外層，負載先解為一般的值再轉成 JSON
+type msgpackEnvelope struct {
+	ID      string      `json:"id,omitempty"`
+	Type    string      `json:"type"`
+	Payload interface{} `json:"payload,omitempty"`
+}
+
+func (msgpackCodec) Name() string   { return SubprotocolMsgpack }
+func (msgpackCodec) FrameType() int { return websocket.BinaryMessage }
+
+func (msgpackCodec) Encode(msg Message) ([]byte, error) {
+	var data []byte
+	err := codec.NewEncoderBytes(&data, msgpackHandle).Encode(msg)
+	return data, err
+}
+
+func (msgpackCodec) Decode(data []byte) (Envelope, error) {
+	var raw msgpackEnvelope
+	if err := codec.NewDecoderBytes(data, msgpackHandle).Decode(&raw); err != nil {
+		return Envelope{}, NewError(ErrCodeInvalidMessage)
+	}
+	env := Envelope{ID: raw.ID, Type: raw.Type}
+	if err := validateRequestID(env.ID); err != nil {
+		env.ID = ""
+		return env, err
+	}
+	if raw.Payload != nil {
+		payload, err := json.Marshal(raw.Payload)
+		if err != nil {
+			return env, NewError(ErrCodeInvalidPayload).With("type", env.Type).With("detail", err.Error())
+		}
+		env.Payload = payload
+	}
+	return env, nil
+}
@@ -0,0 +1,122 @@
+package game
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ugorji/go/codec"
+)
+
+// 以 msgpack 編碼客戶端消息
+func encodeMsgpack(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	var data []byte
+	if err := codec.NewEncoderBytes(&data, &codec.MsgpackHandle{}).Encode(v); err != nil {
+		t.Fatalf("encode msgpack: %v", err)
+	}
+	return data
+}
+
+func TestMsgpackCodecDecode(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        interface{}
+		wantID      string
+		wantType    string
+		wantPayload string
+		wantErr     string
+	}{
+		{
+			name:        "with payload",
+			data:        map[string]interface{}{"id": "r1", "type": "answer", "payload": "red"},
+			wantID:      "r1",
+			wantType:    "answer",
+			wantPayload: `"red"`,
+		},
+		{
+			name:        "object payload",
+			data:        map[string]interface{}{"type": "hello", "payload": map[string]interface{}{"version": 2}},
+			wantType:    "hello",
+			wantPayload: `{"version":2}`,
+		},
+		{
+			name:     "without payload",
+			data:     map[string]interface{}{"type": "ready_check"},
+			wantType: "ready_check",
+		},
+		{
+			name:    "unknown field",
+			data:    map[string]interface{}{"type": "answer", "extra": 1},
+			wantErr: ErrCodeInvalidMessage,
+		},
+		{
+			name:    "request id too long",
+			data:    map[string]interface{}{"id": strings.Repeat("x", MaxRequestIDLength+1), "type": "answer"},
+			wantErr: ErrCodeInvalidMessage,
+		},
+		{
+			name:    "not msgpack",
+			data:    []byte{0xc1},
+			wantErr: ErrCodeInvalidMessage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, ok := tt.data.([]byte)
+			if !ok {
+				data = encodeMsgpack(t, tt.data)
+			}
+			env, err := msgpackCodec{}.Decode(data)
+			if tt.wantErr != "" {
+				if !errors.Is(err, NewError(tt.wantErr)) {
+					t.Fatalf("err = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if env.ID != tt.wantID || env.Type != tt.wantType || string(env.Payload) != tt.wantPayload {
+				t.Fatalf("env = {ID:%q Type:%q Payload:%s}, want {ID:%q Type:%q Payload:%s}",
+					env.ID, env.Type, env.Payload, tt.wantID, tt.wantType, tt.wantPayload)
+			}
+		})
+	}
+}
+
+func TestMsgpackCodecEncodeUsesJSONNames(t *testing.T) {
+	data, err := msgpackCodec{}.Encode(Message{ID: "r1", Seq: 3, Type: MsgTypeAck, Payload: AckPayload{}})
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := codec.NewDecoderBytes(data, msgpackHandle).Decode(&decoded); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	// 欄位名稱與 JSON 相同，空的欄位同樣省略
+	if decoded["id"] != "r1" || decoded["type"] != MsgTypeAck || decoded["seq"] == nil {
+		t.Fatalf("decoded = %v, want id, type and seq by their JSON names", decoded)
+	}
+	if _, ok := decoded["pseq"]; ok {
+		t.Fatalf("decoded = %v, empty pseq should be omitted", decoded)
+	}
+}
+
+func TestCodecFor(t *testing.T) {
+	tests := []struct {
+		subprotocol string
+		want        string
+	}{
+		{subprotocol: SubprotocolMsgpack, want: SubprotocolMsgpack},
+		{subprotocol: SubprotocolJSON, want: SubprotocolJSON},
+		{subprotocol: "", want: SubprotocolJSON},
+		{subprotocol: "cbor", want: SubprotocolJSON},
+	}
+	for _, tt := range tests {
+		if got := CodecFor(tt.subprotocol).Name(); got != tt.want {
+			t.Errorf("CodecFor(%q) = %s, want %s", tt.subprotocol, got, tt.want)
+		}
+	}
+}
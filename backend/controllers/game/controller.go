@@ -1,9 +1,10 @@
 package game
 
 import (
-	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
@@ -12,28 +13,25 @@ import (
 )
 
 func New(base *controllers.Context) *controller {
-	return &controller{Base: base}
+	c := &controller{Base: base}
+	c.matchmaker = NewMatchmaker(DefaultMatchmakingConfig(), c.storeRoom)
+	return c
 }
 
 type controller struct {
-	Base *controllers.Context
-}
-
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true
-	},
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
+	Base       *controllers.Context
+	matchmaker *Matchmaker
+	// SSE 連線，以 session token 為鍵
+	sseConns sync.Map
 }
 
 // 封裝錯誤回應（發送錯誤消息並安全關閉連線）
-func sendErrorAndClose(conn *websocket.Conn, err error) {
+func sendErrorAndClose(conn Conn, err error) {
 	if conn != nil {
-		if writeErr := conn.WriteJSON(Message{
-			Type:    MsgTypeError,
-			Payload: err.Error(),
-		}); writeErr != nil {
+		data, encodeErr := conn.Codec().Encode(NewErrorMessage(err, DefaultErrorLocale))
+		if encodeErr != nil {
+			logger.Output.Error("Error encoding error message: %v", encodeErr)
+		} else if writeErr := conn.WriteMessage(data); writeErr != nil {
 			logger.Output.Error("Error writing error message: %v", writeErr)
 		}
 		conn.Close()
@@ -42,11 +40,6 @@ func sendErrorAndClose(conn *websocket.Conn, err error) {
 
 // 處理 WebSocket 連線的建立與參數驗證
 func (c *controller) HandleWebSocket(ctx *gin.Context) {
-	// 允許跨域，開發環境下允許所有來源
-	upgrader.CheckOrigin = func(r *http.Request) bool {
-		return true
-	}
-
 	// 取得必要參數
 	roomID := ctx.Query("room_id")
 	playerName := ctx.Query("player_name")
@@ -59,24 +52,34 @@ func (c *controller) HandleWebSocket(ctx *gin.Context) {
 	}
 
 	// 升級為 WebSocket 連線
-	conn, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	conn, err := upgradeConn(ctx.Writer, ctx.Request)
 	if err != nil {
 		logger.Output.Error("WebSocket upgrade failed: %v", err)
 		return
 	}
 
 	logger.Output.Info("New WebSocket connection: room=%s, player=%s, host=%v", roomID, playerName, isHost)
+	c.joinRoom(conn, roomID, playerName, isHost, ctx.Query("session"))
+}
 
+// 依玩家身分創建或加入房間，接著進入持續接收並分發玩家消息的循環，直到連線結束
+// session 為先前連線取得的重新連線憑證，有效時沿用原本的玩家身分
+func (c *controller) joinRoom(conn Conn, roomID, playerName string, isHost bool, session string) {
 	// 根據玩家身分決定創建或獲取房間
 	var room *Room
 	if isHost {
+		// 由伺服器主持的房間不可被玩家以房主身分覆蓋
+		if existing := c.getRoom(roomID); existing != nil && existing.ServerHosted {
+			sendErrorAndClose(conn, NewError(ErrCodeRoomExists))
+			return
+		}
 		room = c.createRoom(roomID)
 		logger.Output.Info("Room %s created", room.ID)
 	} else {
 		room = c.getRoom(roomID)
 		if room == nil {
 			logger.Output.Error("Room %s not found", roomID)
-			sendErrorAndClose(conn, errors.New("Room not found"))
+			sendErrorAndClose(conn, NewError(ErrCodeRoomNotFound))
 			return
 		}
 		logger.Output.Info("Room %s found", room.ID)
@@ -84,7 +87,8 @@ func (c *controller) HandleWebSocket(ctx *gin.Context) {
 
 	// 建立玩家並加入房間
 	player := NewPlayer(conn, playerName, isHost)
-	logger.Output.Info("Created new player: %s (host: %v)", player.Name, player.IsHost)
+	room.Resume(player, session)
+	logger.Output.Info("Created new player: %s (host: %v, remote: %s)", player.Name, player.IsHost, conn.RemoteAddr())
 
 	if err := room.AddPlayer(player); err != nil {
 		logger.Output.Error("Failed to add player %s to room %s: %v", player.Name, room.ID, err)
@@ -92,50 +96,366 @@ func (c *controller) HandleWebSocket(ctx *gin.Context) {
 		return
 	}
 	logger.Output.Info("Player %s joined room %s", player.Name, room.ID)
+	room.SendSettings(player)
+	room.SendLateJoinState(player)
 	room.BroadcastPlayerList()
+	room.checkReady()
 
 	// 進入持續接收並分發玩家消息的循環
 	c.handlePlayerMessages(room, player)
 }
 
-// 持續接收玩家消息並委由玩家處理
-func (c *controller) handlePlayerMessages(room *Room, player *Player) {
-	defer func() {
-		if r := recover(); r != nil {
-			logger.Output.Error("Panic in handlePlayerMessages: %v", r)
+// 處理單人練習的 WebSocket 連線：建立不公開的臨時房間並立即開始遊戲
+func (c *controller) HandleSolo(ctx *gin.Context) {
+	playerName := ctx.Query("player_name")
+	if playerName == "" {
+		ctx.String(http.StatusBadRequest, "缺少必要參數")
+		return
+	}
+
+	settings, err := SoloSettings(ctx.Query("mode"), ctx.Query("length"), ctx.Query("language"), ctx.Query("palette"))
+	if err != nil {
+		ctx.String(http.StatusBadRequest, "無效的練習設定")
+		return
+	}
+
+	conn, err := upgradeConn(ctx.Writer, ctx.Request)
+	if err != nil {
+		logger.Output.Error("WebSocket upgrade failed: %v", err)
+		return
+	}
+
+	logger.Output.Info("Player %s started solo practice: mode=%s", playerName, settings.Mode)
+	c.runSoloRoom(conn, NewSoloRoom(settings), playerName)
+}
+
+// 處理每日挑戰的 WebSocket 連線：以當日種子建立單人房間，每位玩家每天只能挑戰一次
+func (c *controller) HandleDaily(ctx *gin.Context) {
+	playerName := ctx.Query("player_name")
+	if playerName == "" {
+		ctx.String(http.StatusBadRequest, "缺少必要參數")
+		return
+	}
+
+	settings, err := DailySettings(ctx.Query("language"))
+	if err != nil {
+		ctx.String(http.StatusBadRequest, "無效的挑戰設定")
+		return
+	}
+
+	conn, err := upgradeConn(ctx.Writer, ctx.Request)
+	if err != nil {
+		logger.Output.Error("WebSocket upgrade failed: %v", err)
+		return
+	}
+
+	date := GlobalDailyBoard.Today()
+	if err := GlobalDailyBoard.Claim(date, playerName); err != nil {
+		logger.Output.Info("Player %s rejected from daily challenge %s: %v", playerName, date, err)
+		sendErrorAndClose(conn, err)
+		return
+	}
+	logger.Output.Info("Player %s started daily challenge %s", playerName, date)
+	c.runSoloRoom(conn, NewDailyRoom(date, settings), playerName)
+	// 未完成挑戰就離開時釋放登記，玩家可以重新開始
+	GlobalDailyBoard.Release(date, playerName)
+}
+
+// 查詢每日挑戰排行榜：前 N 名與查詢者本身的名次
+func (c *controller) GetDailyLeaderboard(ctx *gin.Context) {
+	limit := DefaultDailyBoardSize
+	if value := ctx.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > MaxDailyBoardSize {
+			ctx.String(http.StatusBadRequest, "無效的排行榜數量")
+			return
 		}
-		room.RemovePlayer(player.ID)
-		logger.Output.Info("Player %s left room %s", player.Name, room.ID)
-		room.BroadcastPlayerList()
-		player.Close()
+		limit = n
+	}
+	ctx.JSON(http.StatusOK, GlobalDailyBoard.Leaderboard(ctx.Query("player_name"), limit))
+}
+
+// 處理挑戰連結的 WebSocket 連線：以挑戰者的題目序列開始單人遊戲，結束後比較成績
+func (c *controller) HandleChallenge(ctx *gin.Context) {
+	code := ctx.Query("code")
+	playerName := ctx.Query("player_name")
+	if code == "" || playerName == "" {
+		ctx.String(http.StatusBadRequest, "缺少必要參數")
+		return
+	}
+
+	challenge := GlobalChallengeStore.Get(code)
+	if challenge == nil {
+		ctx.String(http.StatusNotFound, ErrCodeChallengeNotFound)
+		return
+	}
+	room, err := NewChallengeRoom(challenge, ctx.Query("language"))
+	if err != nil {
+		ctx.String(http.StatusBadRequest, "無效的挑戰設定")
+		return
+	}
+
+	conn, err := upgradeConn(ctx.Writer, ctx.Request)
+	if err != nil {
+		logger.Output.Error("WebSocket upgrade failed: %v", err)
+		return
+	}
+
+	logger.Output.Info("Player %s accepted challenge %s from %s", playerName, code, challenge.Result.Name)
+	c.runSoloRoom(conn, room, playerName)
+}
+
+// 查詢挑戰資訊（挑戰者成績與模式設定）
+func (c *controller) GetChallenge(ctx *gin.Context) {
+	challenge := GlobalChallengeStore.Get(ctx.Param("code"))
+	if challenge == nil {
+		ctx.String(http.StatusNotFound, ErrCodeChallengeNotFound)
+		return
+	}
+	ctx.JSON(http.StatusOK, challenge)
+}
+
+// 建立由伺服器自動主持的房間（例如展場的自助機台），玩家以一般方式加入即可
+func (c *controller) CreateAutoHostRoom(ctx *gin.Context) {
+	body := struct {
+		ID       string          `json:"id"`
+		Settings *RoomSettings   `json:"settings"`
+		AutoHost *AutoHostConfig `json:"autoHost"`
+	}{}
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.String(http.StatusBadRequest, ErrCodeInvalidMessage)
+		return
+	}
+
+	settings := DefaultRoomSettings()
+	if body.Settings != nil {
+		settings = *body.Settings
+		if err := settings.resolvePalette(); err != nil {
+			ctx.String(http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	config := DefaultAutoHostConfig()
+	if body.AutoHost != nil {
+		config = *body.AutoHost
+	}
+
+	if body.ID == "" {
+		code, err := newShortCode(ChallengeCodeLength)
+		if err != nil {
+			ctx.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		body.ID = code
+	}
+	if c.getRoom(body.ID) != nil {
+		ctx.String(http.StatusConflict, ErrCodeRoomExists)
+		return
+	}
+
+	room, err := NewAutoHostRoom(body.ID, settings, config)
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	room.Persistent = true
+	c.storeRoom(room)
+	logger.Output.Info("Auto-host room %s created", room.ID)
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"id":       room.ID,
+		"settings": room.Settings,
+		"autoHost": room.AutoHost,
+	})
+}
+
+// 返回 WebSocket 協定的 JSON Schema 文件
+func (c *controller) GetProtocolSchema(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, ProtocolSchema())
+}
+
+// 返回速率限制的累計次數，供監控使用
+func (c *controller) GetRateLimitStats(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, GetRateLimitStats())
+}
+
+// 處理快速配對的 WebSocket 連線：玩家進入佇列，配對成功後自動加入由伺服器主持的房間並開始遊戲
+func (c *controller) HandleQuickPlay(ctx *gin.Context) {
+	playerName := ctx.Query("player_name")
+	if playerName == "" {
+		ctx.String(http.StatusBadRequest, "缺少必要參數")
+		return
+	}
 
-		if len(room.Players) == 0 {
-			c.removeRoom(room.ID)
-			logger.Output.Info("Room %s deleted", room.ID)
+	mode := GameMode(ctx.Query("mode"))
+	if mode == "" {
+		mode = GameModeClassic
+	}
+	var rating *int
+	if value := ctx.Query("rating"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			ctx.String(http.StatusBadRequest, ErrCodeInvalidSettings)
+			return
 		}
-	}()
+		rating = &n
+	}
+
+	conn, err := upgradeConn(ctx.Writer, ctx.Request)
+	if err != nil {
+		logger.Output.Error("WebSocket upgrade failed: %v", err)
+		return
+	}
 
+	player := NewPlayer(conn, playerName, false)
+	ticket, err := c.matchmaker.Enqueue(player, mode, rating)
+	if err != nil {
+		sendErrorAndClose(conn, err)
+		return
+	}
+	c.handleQueuedPlayer(ticket)
+}
+
+// 排隊期間只接受離開佇列的消息；配對成功後轉入一般的房間消息循環
+func (c *controller) handleQueuedPlayer(ticket *QueueTicket) {
+	player := ticket.Player
 	for {
-		_, messageData, err := player.Conn.ReadMessage()
+		messageData, err := player.Conn.ReadMessage()
 		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				logger.Output.Error("WebSocket unexpected close: %v", err)
+			if errors.Is(err, websocket.ErrReadLimit) {
+				logger.Output.Error("Player %s sent a message larger than %d bytes, connection closed", player.Name, wsConfig.MaxMessageSize)
+			}
+			if room := c.matchmaker.Leave(ticket); room != nil {
+				c.leaveRoom(room, player)
+			} else {
+				player.Close()
 			}
 			return
 		}
 
-		var msg Message
-		if err := json.Unmarshal(messageData, &msg); err != nil {
-			logger.Output.Error("Failed to unmarshal message: %v", err)
-			player.SendError(ErrCodeInvalidMessage, "無效的消息格式")
+		if room := c.matchmaker.Room(ticket); room != nil {
+			c.dispatchMessage(room, player, messageData)
+			c.handlePlayerMessages(room, player)
+			return
+		}
+
+		env, err := player.Conn.Codec().Decode(messageData)
+		if !player.allowMessage(env) {
 			continue
 		}
+		var payload interface{}
+		if err == nil {
+			payload, err = decodeEnvelopePayload(env)
+		}
+		if err != nil {
+			player.replyError(env.ID, err)
+			continue
+		}
+		if env.Type == MsgTypeHello {
+			player.handleRequest(env, nil, func() error {
+				return player.handleHello(*payload.(*HelloPayload), nil)
+			})
+			continue
+		}
+		if env.Type != MsgTypeLeaveQueue {
+			player.replyError(env.ID, NewError(ErrCodeInQueue))
+			continue
+		}
+		if room := c.matchmaker.Leave(ticket); room != nil {
+			// 離開前剛好配對成功，視同離開房間
+			c.leaveRoom(room, player)
+		} else {
+			player.Close()
+		}
+		return
+	}
+}
+
+// 將玩家加入單人房間並立即開始遊戲，接著進入消息循環
+func (c *controller) runSoloRoom(conn Conn, room *Room, playerName string) {
+	player := NewPlayer(conn, playerName, false)
+	player.IsReady = true
+	if err := room.AddPlayer(player); err != nil {
+		sendErrorAndClose(conn, err)
+		return
+	}
+
+	room.SendSettings(player)
+	if err := room.StartGame(); err != nil {
+		logger.Output.Error("Failed to start solo room %s: %v", room.ID, err)
+		sendErrorAndClose(conn, err)
+		return
+	}
+
+	c.handlePlayerMessages(room, player)
+}
+
+// 持續接收玩家消息並委由玩家處理
+func (c *controller) handlePlayerMessages(room *Room, player *Player) {
+	defer c.leaveRoom(room, player)
+
+	for {
+		messageData, err := player.Conn.ReadMessage()
+		if err != nil {
+			if errors.Is(err, websocket.ErrReadLimit) {
+				logger.Output.Error("Player %s sent a message larger than %d bytes, connection closed", player.Name, wsConfig.MaxMessageSize)
+			} else if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				logger.Output.Error("WebSocket unexpected close: %v", err)
+			}
+			return
+		}
+		c.dispatchMessage(room, player, messageData)
+	}
+}
 
-		logger.Output.Info("Received message from player %s: type=%s, payload=%v", player.Name, msg.Type, msg.Payload)
-		if err := player.HandleMessage(msg, room); err != nil {
+// 解析單則玩家消息並交由玩家處理，解碼或處理失敗時回應統一格式的錯誤
+func (c *controller) dispatchMessage(room *Room, player *Player, messageData []byte) {
+	env, err := player.Conn.Codec().Decode(messageData)
+	if !player.allowMessage(env) {
+		return
+	}
+	if err == nil {
+		player.adaptInbound(&env)
+	}
+	var payload interface{}
+	if err == nil {
+		payload, err = decodeEnvelopePayload(env)
+	}
+	if err != nil {
+		logger.Output.Error("Failed to decode message from player %s: %v", player.Name, err)
+		player.replyError(env.ID, err)
+		return
+	}
+
+	logger.Output.Debug("Received message from player %s: type=%s, id=%s, payload=%s", player.Name, env.Type, env.ID, env.Payload)
+	player.handleRequest(env, room, func() error {
+		err := player.HandleMessage(env.Type, payload, room)
+		if err != nil {
 			logger.Output.Error("Error processing message for player %s: %v", player.Name, err)
-			player.SendError(ErrCodeInvalidMessage, err.Error())
 		}
+		return err
+	})
+}
+
+// 玩家斷線後移出房間，房間沒有玩家時一併移除
+func (c *controller) leaveRoom(room *Room, player *Player) {
+	if r := recover(); r != nil {
+		logger.Output.Error("Panic in handlePlayerMessages: %v", r)
+	}
+	room.RemovePlayer(player.ID)
+	logger.Output.Info("Player %s left room %s", player.Name, room.ID)
+	room.BroadcastPlayerList()
+	room.checkReady()
+	player.Close()
+
+	// 只剩機器人時一併移出，讓房間可以被移除
+	if !room.Persistent && !room.hasHumans() {
+		room.RemoveBots()
+	}
+	if len(room.Players) == 0 && !room.Persistent {
+		room.Close()
+		c.removeRoom(room.ID)
+		logger.Output.Info("Room %s deleted", room.ID)
 	}
 }
 
@@ -146,6 +466,11 @@ func (c *controller) createRoom(id string) *Room {
 	return room
 }
 
+// 將已建立的房間存入 Context（例如錦標賽的分組賽房間）
+func (c *controller) storeRoom(room *Room) {
+	c.Base.GameRooms.Store(room.ID, room)
+}
+
 // 從 Context 中獲取房間
 func (c *controller) getRoom(id string) *Room {
 	if room, ok := c.Base.GameRooms.Load(id); ok {
@@ -0,0 +1,250 @@
+package game
+
+import (
+	"hash/fnv"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rejxcy/logger"
+)
+
+// 每日挑戰相關常數
+const (
+	DailyQuizCount        = 20
+	DefaultDailyBoardSize = 10
+	MaxDailyBoardSize     = 100
+	dailyDateLayout       = "2006-01-02"
+	defaultDailyTimezone  = "Asia/Taipei"
+)
+
+// 全域的每日挑戰排行榜
+var GlobalDailyBoard = NewDailyBoard(defaultDailyLocation())
+
+// 每日排行榜中的單筆成績
+type DailyEntry struct {
+	Rank       int    `json:"rank"`
+	Name       string `json:"name"`
+	Score      int    `json:"score"`
+	WrongCount int    `json:"wrongCount"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// 每日排行榜查詢結果：前 N 名與查詢者本身的名次
+type DailyLeaderboard struct {
+	Date     string       `json:"date"`
+	Entries  []DailyEntry `json:"entries"`
+	Self     *DailyEntry  `json:"self"`
+	Players  int          `json:"players"`
+	ResetsAt time.Time    `json:"resetsAt"`
+}
+
+// DailyBoard 保存當日每位玩家的挑戰紀錄，跨過時區的日期邊界時自動清空
+type DailyBoard struct {
+	location *time.Location
+	date     string
+	// 已開始挑戰的玩家；成績為 nil 表示尚未完成
+	runs map[string]*GameRecord
+	mu   sync.Mutex
+}
+
+// NewDailyBoard 創建以指定時區換日的每日排行榜
+func NewDailyBoard(location *time.Location) *DailyBoard {
+	return &DailyBoard{
+		location: location,
+		runs:     make(map[string]*GameRecord),
+	}
+}
+
+// 預設時區載入失敗時（例如缺少時區資料）退回 UTC
+func defaultDailyLocation() *time.Location {
+	location, err := time.LoadLocation(defaultDailyTimezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// SetTimezone 設定每日挑戰換日的時區
+func (b *DailyBoard) SetTimezone(name string) error {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.location = location
+	return nil
+}
+
+// Today 返回目前時區下的挑戰日期
+func (b *DailyBoard) Today() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.rollLocked(time.Now())
+}
+
+// 依目前時間判斷是否換日，換日時清空紀錄，呼叫時需持有 b.mu
+func (b *DailyBoard) rollLocked(now time.Time) string {
+	today := now.In(b.location).Format(dailyDateLayout)
+	if today != b.date {
+		b.date = today
+		b.runs = make(map[string]*GameRecord)
+	}
+	return today
+}
+
+// 下一次換日的時間
+func (b *DailyBoard) resetsAtLocked(now time.Time) time.Time {
+	local := now.In(b.location)
+	return time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, b.location)
+}
+
+// Claim 登記玩家開始當日挑戰，每位玩家每天只能挑戰一次
+func (b *DailyBoard) Claim(date, playerName string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rollLocked(time.Now()) != date {
+		return NewError(ErrCodeDailyExpired)
+	}
+	if _, ok := b.runs[playerName]; ok {
+		return NewError(ErrCodeDailyAlreadyPlayed)
+	}
+	b.runs[playerName] = nil
+	return nil
+}
+
+// Release 釋放尚未提交成績的挑戰登記（例如中途斷線），玩家可重新挑戰；已提交或已換日時不做任何事
+func (b *DailyBoard) Release(date, playerName string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rollLocked(time.Now()) != date {
+		return
+	}
+	if run, ok := b.runs[playerName]; ok && run == nil {
+		delete(b.runs, playerName)
+	}
+}
+
+// Submit 提交玩家當日挑戰的成績，只接受已登記且尚未提交的挑戰
+func (b *DailyBoard) Submit(date, playerName string, rec GameRecord) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rollLocked(time.Now()) != date {
+		return NewError(ErrCodeDailyExpired)
+	}
+	run, ok := b.runs[playerName]
+	if !ok || run != nil {
+		return NewError(ErrCodeDailyAlreadyPlayed)
+	}
+	b.runs[playerName] = &rec
+	return nil
+}
+
+// Leaderboard 返回當日前 limit 名與指定玩家的名次
+func (b *DailyBoard) Leaderboard(playerName string, limit int) DailyLeaderboard {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	date := b.rollLocked(now)
+
+	type run struct {
+		name   string
+		record GameRecord
+	}
+	runs := make([]run, 0, len(b.runs))
+	for name, rec := range b.runs {
+		if rec != nil {
+			runs = append(runs, run{name: name, record: *rec})
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		a, b := runs[i].record, runs[j].record
+		if a.Better(b) {
+			return true
+		}
+		if b.Better(a) {
+			return false
+		}
+		return runs[i].name < runs[j].name
+	})
+
+	board := DailyLeaderboard{
+		Date:     date,
+		Entries:  make([]DailyEntry, 0, limit),
+		Players:  len(runs),
+		ResetsAt: b.resetsAtLocked(now),
+	}
+	for i, r := range runs {
+		entry := DailyEntry{
+			Rank:       i + 1,
+			Name:       r.name,
+			Score:      r.record.Score,
+			WrongCount: r.record.WrongCount,
+			DurationMs: r.record.DurationMs,
+		}
+		if i < limit {
+			board.Entries = append(board.Entries, entry)
+		}
+		if r.name == playerName {
+			self := entry
+			board.Self = &self
+		}
+	}
+	return board
+}
+
+// 由日期推導當日題目的種子，同一天所有玩家拿到相同題目
+func dailySeed(date string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("colorgame-daily-" + date))
+	return int64(h.Sum64())
+}
+
+// DailySettings 每日挑戰固定使用預設調色盤與題數，只允許調整題目語言
+func DailySettings(language string) (RoomSettings, error) {
+	settings := DefaultRoomSettings()
+	settings.QuizCount = DailyQuizCount
+	if language != "" {
+		settings.QuizLanguage = language
+	}
+	return settings, settings.Validate()
+}
+
+// NewDailyRoom 創建指定日期的每日挑戰房間
+func NewDailyRoom(date string, settings RoomSettings) *Room {
+	room := NewSoloRoom(settings)
+	room.Daily = date
+	room.Seed = dailySeed(date)
+	return room
+}
+
+// 每日挑戰結束時提交成績並通知玩家目前名次
+func (r *Room) submitDailyResults() {
+	r.mu.Lock()
+	date := r.Daily
+	type result struct {
+		player *Player
+		record GameRecord
+	}
+	results := make([]result, 0, len(r.Players))
+	for _, p := range r.Players {
+		if !p.IsHost {
+			results = append(results, result{player: p, record: NewGameRecord(p)})
+		}
+	}
+	r.mu.Unlock()
+
+	for _, res := range results {
+		if err := GlobalDailyBoard.Submit(date, res.player.Name, res.record); err != nil {
+			logger.Output.Error("提交 %s 每日挑戰成績失敗: %v", res.player.Name, err)
+			res.player.SendError(err)
+			continue
+		}
+		board := GlobalDailyBoard.Leaderboard(res.player.Name, DefaultDailyBoardSize)
+		if err := res.player.Send(Message{Type: MsgTypeDailyResult, Payload: board}); err != nil {
+			logger.Output.Error("發送每日挑戰結果給 %s 失敗: %v", res.player.Name, err)
+		}
+	}
+}
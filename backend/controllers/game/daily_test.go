@@ -0,0 +1,89 @@
+package game
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDailyBoardClaim(t *testing.T) {
+	tests := []struct {
+		name string
+		// 依序執行的操作：claim、submit、release
+		steps   []string
+		wantErr string
+	}{
+		{name: "first claim", steps: []string{"claim"}},
+		{name: "claim twice", steps: []string{"claim", "claim"}, wantErr: ErrCodeDailyAlreadyPlayed},
+		{name: "claim after submit", steps: []string{"claim", "submit", "claim"}, wantErr: ErrCodeDailyAlreadyPlayed},
+		{name: "claim after abandoned run", steps: []string{"claim", "release", "claim"}},
+		{name: "release keeps submitted run", steps: []string{"claim", "submit", "release", "claim"}, wantErr: ErrCodeDailyAlreadyPlayed},
+		{name: "submit without claim", steps: []string{"submit"}, wantErr: ErrCodeDailyAlreadyPlayed},
+		{name: "submit after release", steps: []string{"claim", "release", "submit"}, wantErr: ErrCodeDailyAlreadyPlayed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			board := NewDailyBoard(time.UTC)
+			date := board.Today()
+			var err error
+			for _, step := range tt.steps {
+				switch step {
+				case "claim":
+					err = board.Claim(date, "alice")
+				case "submit":
+					err = board.Submit(date, "alice", GameRecord{Score: 10})
+				case "release":
+					board.Release(date, "alice")
+					err = nil
+				}
+			}
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && !errors.Is(err, NewError(tt.wantErr)) {
+				t.Fatalf("err = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDailyBoardExpiredDate(t *testing.T) {
+	board := NewDailyBoard(time.UTC)
+	if err := board.Claim("2000-01-01", "alice"); !errors.Is(err, NewError(ErrCodeDailyExpired)) {
+		t.Fatalf("err = %v, want %s", err, ErrCodeDailyExpired)
+	}
+}
+
+func TestDailyLeaderboard(t *testing.T) {
+	board := NewDailyBoard(time.UTC)
+	date := board.Today()
+	records := map[string]GameRecord{
+		"alice": {Score: 10, DurationMs: 5000},
+		"bob":   {Score: 30, DurationMs: 9000},
+		"carol": {Score: 20, DurationMs: 7000},
+	}
+	for name, rec := range records {
+		if err := board.Claim(date, name); err != nil {
+			t.Fatalf("claim %s: %v", name, err)
+		}
+		if err := board.Submit(date, name, rec); err != nil {
+			t.Fatalf("submit %s: %v", name, err)
+		}
+	}
+	// 尚未完成的挑戰不列入排行榜
+	if err := board.Claim(date, "dave"); err != nil {
+		t.Fatalf("claim dave: %v", err)
+	}
+
+	lb := board.Leaderboard("alice", 2)
+	if lb.Players != 3 || len(lb.Entries) != 2 {
+		t.Fatalf("players %d entries %d, want 3 and 2", lb.Players, len(lb.Entries))
+	}
+	if lb.Entries[0].Name != "bob" || lb.Entries[1].Name != "carol" {
+		t.Fatalf("entries = %+v, want bob then carol", lb.Entries)
+	}
+	if lb.Self == nil || lb.Self.Rank != 3 {
+		t.Fatalf("self = %+v, want alice ranked 3", lb.Self)
+	}
+}
@@ -1,9 +1,9 @@
 package game
 
 import (
+	"encoding/json"
 	"sync"
-
-	"github.com/gorilla/websocket"
+	"time"
 )
 
 // RoomStatus 定義房間狀態類型
@@ -11,44 +11,119 @@ type RoomStatus string
 
 // 房間狀態常數
 const (
-	RoomStatusWaiting  RoomStatus = "waiting"
-	RoomStatusPlaying  RoomStatus = "playing"
-	RoomStatusFinished RoomStatus = "finished"
+	RoomStatusWaiting      RoomStatus = "waiting"
+	RoomStatusPlaying      RoomStatus = "playing"
+	RoomStatusFinished     RoomStatus = "finished"
+	RoomStatusIntermission RoomStatus = "intermission"
+)
+
+// GameMode 定義遊戲模式
+type GameMode string
+
+// 遊戲模式常數：一般模式答完固定題數，計時模式在時間內盡量作答
+const (
+	GameModeClassic GameMode = "classic"
+	GameModeTimed   GameMode = "timed"
 )
 
 // WebSocket 消息類型常數
 const (
-	MsgTypeAnswer      = "answer"
-	MsgTypeGameState   = "game_state"
-	MsgTypeGameEnd     = "game_end"
-	MsgTypeError       = "error"
-	MsgTypeJoinRoom    = "join_room"
-	MsgTypeLeaveRoom   = "leave_room"
-	MsgTypePlayerList  = "player_list"
-	MsgTypeGameStart   = "game_start"
-	MsgTypeProgress    = "progress"
-	MsgTypeReady       = "ready"
-	MsgTypeGameReset   = "game_reset"
+	MsgTypeAnswer           = "answer"
+	MsgTypeGameState        = "game_state"
+	MsgTypeGameEnd          = "game_end"
+	MsgTypeError            = "error"
+	MsgTypeJoinRoom         = "join_room"
+	MsgTypeLeaveRoom        = "leave_room"
+	MsgTypePlayerList       = "player_list"
+	MsgTypeGameStart        = "game_start"
+	MsgTypeProgress         = "progress"
+	MsgTypeReady            = "ready"
+	MsgTypeGameReset        = "game_reset"
+	MsgTypeUpdateSettings   = "update_settings"
+	MsgTypeRoomSettings     = "room_settings"
+	MsgTypeRoundEnd         = "round_end"
+	MsgTypeMatchEnd         = "match_end"
+	MsgTypeScoreEvent       = "score_event"
+	MsgTypeSetLanguage      = "set_language"
+	MsgTypePersonalBest     = "personal_best"
+	MsgTypeDailyResult      = "daily_result"
+	MsgTypeCreateChallenge  = "create_challenge"
+	MsgTypeChallengeCreated = "challenge_created"
+	MsgTypeChallengeResult  = "challenge_result"
+	MsgTypeTournamentState  = "tournament_state"
+	MsgTypeQueueStatus      = "queue_status"
+	MsgTypeMatchFound       = "match_found"
+	MsgTypeLeaveQueue       = "leave_queue"
+	MsgTypeAutoHost         = "auto_host"
+	MsgTypeReadyStatus      = "ready_status"
+	MsgTypeKicked           = "kicked"
+	MsgTypeHello            = "hello"
+	MsgTypeWelcome          = "welcome"
+	MsgTypeAck              = "ack"
+	MsgTypeSync             = "sync"
+	MsgTypeSnapshot         = "snapshot"
+	MsgTypePlayerListDelta  = "player_list_delta"
+	MsgTypeAddBots          = "add_bots"
+	MsgTypeRemoveBots       = "remove_bots"
 )
 
 // 遊戲相關常數
 const (
-	MaxPlayers    = 10
-	MinPlayers    = 1
-	QuizCount = 10
+	MaxPlayers       = 10
+	MinPlayers       = 1
+	QuizCount        = 10
+	MaxQuizCount     = 100
+	DefaultTimeLimit = 60
+	MinTimeLimit     = 10
+	MaxTimeLimit     = 600
+	DefaultRounds    = 1
+	MaxRounds        = 10
+	MaxScoreValue    = 1000
+	MaxMultiplier    = 10
+)
+
+// 分數變化原因
+const (
+	ScoreReasonCorrect       = "correct"
+	ScoreReasonCombo         = "combo"
+	ScoreReasonFirstAnswer   = "first_answer"
+	ScoreReasonWrong         = "wrong"
+	ScoreReasonPenaltyCapped = "penalty_capped"
 )
 
 // 錯誤碼與錯誤訊息
 const (
-	ErrCodeRoomFull         = "room_full"
-	ErrCodeNotHost          = "not_host"
-	ErrCodeGameNotStarted   = "game_not_started"
-	ErrCodeGameInProgress   = "game_in_progress"
-	ErrCodeNotReady         = "not_ready"
-	ErrCodeNotEnoughPlayers = "not_enough_players"
-	ErrCodeInvalidAnswer    = "invalid_answer"
-	ErrCodePlayerNotFound   = "player_not_found"
-	ErrCodeInvalidMessage   = "invalid_message"
+	ErrCodeRoomFull            = "room_full"
+	ErrCodeNotHost             = "not_host"
+	ErrCodeGameNotStarted      = "game_not_started"
+	ErrCodeGameInProgress      = "game_in_progress"
+	ErrCodeNotReady            = "not_ready"
+	ErrCodeNotEnoughPlayers    = "not_enough_players"
+	ErrCodeInvalidAnswer       = "invalid_answer"
+	ErrCodePlayerNotFound      = "player_not_found"
+	ErrCodeInvalidMessage      = "invalid_message"
+	ErrCodeInvalidSettings     = "invalid_settings"
+	ErrCodeInvalidPalette      = "invalid_palette"
+	ErrCodeDailyAlreadyPlayed  = "daily_already_played"
+	ErrCodeDailyExpired        = "daily_expired"
+	ErrCodeGameNotFinished     = "game_not_finished"
+	ErrCodeChallengeNotFound   = "challenge_not_found"
+	ErrCodeTournamentNotFound  = "tournament_not_found"
+	ErrCodeTournamentStarted   = "tournament_started"
+	ErrCodeAlreadyRegistered   = "already_registered"
+	ErrCodeNotRegistered       = "not_registered"
+	ErrCodeHeatNotFound        = "heat_not_found"
+	ErrCodeRoomExists          = "room_exists"
+	ErrCodeInQueue             = "in_queue"
+	ErrCodeSpectator           = "spectator"
+	ErrCodeRoomNotFound        = "room_not_found"
+	ErrCodeGameFinished        = "game_finished"
+	ErrCodeHostCannotReady     = "host_cannot_ready"
+	ErrCodeHostCannotChallenge = "host_cannot_challenge"
+	ErrCodeConnectionClosed    = "connection_closed"
+	ErrCodeInternal            = "internal_error"
+	ErrCodeRateLimited         = "rate_limited"
+	ErrCodeInvalidBotConfig    = "invalid_bot_config"
 )
 
 type RoomManager struct {
@@ -57,16 +132,160 @@ type RoomManager struct {
 }
 
 type Room struct {
-	ID        string
-	Players   map[string]*Player
-	Status    RoomStatus
-	mu        sync.Mutex
+	ID       string
+	Players  map[string]*Player
+	Status   RoomStatus
+	Settings RoomSettings
+	Match    *Match
+	// 單人練習房間不會出現在公開房間列表中，也不需要房主
+	Solo bool
+	// 每日挑戰的日期，非每日挑戰時為空
+	Daily string
+	// 固定的題目種子，0 表示每局隨機
+	Seed int64
+	// 以挑戰連結進入時要比較的挑戰
+	Challenge *Challenge
+	// 由伺服器主持的房間（例如錦標賽分組賽、快速配對），不需要房主也不檢查準備狀態
+	ServerHosted bool
+	// 沒有玩家時也不會被移除的房間（例如等待選手進場的錦標賽分組賽）
+	Persistent bool
+	// 自動主持規則，非 nil 時由伺服器依規則自動開始、休息與重置
+	AutoHost *AutoHostConfig
+	// 允許加入的玩家名稱，nil 表示不限制
+	AllowedNames map[string]bool
+	// 比賽（所有回合）結束時的回呼，傳入最終排名
+	OnMatchEnd func(standings []MatchStanding)
+	// 共用題目時記錄已被答對的題目索引，用於首答加分
+	firstCorrect map[int]bool
+	// 本回合的題目種子與開始時間，供中途加入的玩家使用
+	roundSeed      int64
+	roundStartedAt time.Time
+	// 計時模式的結束計時器
+	timer *time.Timer
+	// 準備檢查的逾時計時器與截止時間
+	readyTimer    *time.Timer
+	readyDeadline time.Time
+	// 自動主持的倒數或休息計時器與狀態
+	autoHostTimer  *time.Timer
+	autoHostState  string
+	autoHostEndsAt time.Time
+	// 作答後合併玩家列表更新的計時器，非 nil 表示有尚未廣播的更新
+	broadcastTimer *time.Timer
+	// 上一次廣播的玩家列表與廣播次數，用於計算差異更新
+	lastPlayerList    map[string]PlayerListEntry
+	playerListUpdates int
+	// 已加入過的機器人數量，用於命名
+	botCount int
+	// 最近的廣播事件與其序號
+	events eventLog
+	// 各玩家最近的遊戲消息（遊戲狀態、分數變化）與其序號，以玩家 ID 為鍵
+	playerEvents map[string]*eventLog
+	// 房間內玩家近期處理過的請求結果，以玩家 ID 與請求 ID 為鍵，重新連線後仍可去重
+	requests requestCache
+	// 已發放的重新連線憑證
+	sessions map[string]playerSession
+	mu       sync.Mutex
+}
+
+// 房主可調整的房間設定
+type RoomSettings struct {
+	Mode          GameMode     `json:"mode"`
+	QuizCount     int          `json:"quizCount"`
+	TimeLimit     int          `json:"timeLimit"`
+	Rounds        int          `json:"rounds"`
+	SharedQuiz    bool         `json:"sharedQuiz"`
+	Scoring       ScoringRules `json:"scoring"`
+	PalettePreset string       `json:"palettePreset"`
+	Palette       Palette      `json:"palette"`
+	// 題目文字語言，設定第二語言時兩種語言交替出題
+	QuizLanguage      string `json:"quizLanguage"`
+	SecondaryLanguage string `json:"secondaryLanguage"`
+	// 準備檢查規則
+	ReadyCheck ReadyCheckRules `json:"readyCheck"`
+	// 遊戲進行中加入的玩家處理方式
+	LateJoin string `json:"lateJoin"`
+}
+
+// 準備檢查規則：達到準備比例即可開始；設定逾時時，第一位玩家準備後仍未準備的玩家會被踢出或轉為觀戰
+type ReadyCheckRules struct {
+	ReadyPercent   int    `json:"readyPercent"`
+	TimeoutSeconds int    `json:"timeoutSeconds"`
+	OnTimeout      string `json:"onTimeout"`
+}
+
+// 調色盤中的單一顏色：顯示名稱、色碼與作答時使用的鍵值
+type PaletteColor struct {
+	Key   string            `json:"key"`
+	Name  string            `json:"name"`
+	Hex   string            `json:"hex"`
+	Words map[string]string `json:"words,omitempty"`
+}
+
+// 遊戲使用的調色盤
+type Palette struct {
+	Name   string         `json:"name"`
+	Colors []PaletteColor `json:"colors"`
+}
+
+// 計分規則，數值為 0 表示停用該項規則
+type ScoringRules struct {
+	CorrectPoints    int `json:"correctPoints"`
+	WrongPenalty     int `json:"wrongPenalty"`
+	StreakStep       int `json:"streakStep"`
+	MaxMultiplier    int `json:"maxMultiplier"`
+	ComboSize        int `json:"comboSize"`
+	ComboBonus       int `json:"comboBonus"`
+	FirstAnswerBonus int `json:"firstAnswerBonus"`
+	PenaltyCap       int `json:"penaltyCap"`
+}
+
+// 單次分數變化事件，供前端顯示動畫
+type ScoreEvent struct {
+	PlayerID   string `json:"playerId"`
+	Name       string `json:"name"`
+	Delta      int    `json:"delta"`
+	Reason     string `json:"reason"`
+	Streak     int    `json:"streak"`
+	Multiplier int    `json:"multiplier"`
+	Score      int    `json:"score"`
 }
 
-// WebSocket 的消息格式
+// 多回合比賽的進度與累積分數
+type Match struct {
+	TotalRounds  int               `json:"totalRounds"`
+	CurrentRound int               `json:"currentRound"`
+	RoundScores  []map[string]int  `json:"roundScores"`
+	TotalScores  map[string]int    `json:"totalScores"`
+	PlayerNames  map[string]string `json:"playerNames"`
+}
+
+// 比賽排名中單一玩家的資訊
+type MatchStanding struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	RoundScores []int  `json:"roundScores"`
+	TotalScore  int    `json:"totalScore"`
+	Rank        int    `json:"rank"`
+}
+
+// WebSocket 的消息格式（伺服器發送）
 type Message struct {
-	Type    string      `json:"type"`
-	Payload interface{} `json:"payload"`
+	// 回覆客戶端指令時帶上該指令的請求 ID
+	ID string `json:"id,omitempty"`
+	// 房間廣播事件的序號，依廣播順序遞增；只發給單一玩家的消息不帶序號
+	Seq uint64 `json:"seq,omitempty"`
+	// 只發給單一玩家的遊戲消息（遊戲狀態、分數變化）的序號，每位玩家各自遞增
+	PlayerSeq uint64      `json:"pseq,omitempty"`
+	Type      string      `json:"type"`
+	Payload   interface{} `json:"payload"`
+}
+
+// 客戶端發送的消息，負載保留原始 JSON，依消息類型解碼為對應的結構
+type Envelope struct {
+	// 選填的請求 ID，伺服器會在 ack 或 error 回覆中帶回
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
 }
 
 // 遊戲內所有狀態與數據
@@ -79,24 +298,63 @@ type Game struct {
 	WrongCount   int       `json:"wrong_count"`
 	IsFinished   bool      `json:"is_finished"`
 	PlayerID     string    `json:"player_id"`
+	Streak       int       `json:"streak"`
+	PenaltyTotal int       `json:"penalty_total"`
+	Mode         GameMode  `json:"mode"`
+	Seed         int64     `json:"seed"`
+	TimeLimit    int       `json:"time_limit"`
+	StartedAt    time.Time `json:"started_at"`
+	FinishedAt   time.Time `json:"finished_at"`
+	Deadline     time.Time `json:"deadline"`
+	// 每題答對所花的時間（毫秒），以及目前題目開始的時間
+	QuestionTimesMs   []int64   `json:"question_times_ms"`
+	QuestionStartedAt time.Time `json:"question_started_at"`
+	palette           Palette
+	languages         []string
 }
 
 // 為前端提供的遊戲狀態資訊
 type GameStatus struct {
-	Quiz         string  `json:"quiz"`
-	DisplayColor string  `json:"displayColor"`
-	Progress     int     `json:"progress"`
-	WrongCount   int     `json:"wrongCount"`
-	TotalQuiz    int     `json:"totalQuiz"`
-	IsFinished   bool    `json:"isFinished"`
+	Mode         GameMode `json:"mode"`
+	EndsAt       int64    `json:"endsAt"`
+	Quiz         string   `json:"quiz"`
+	QuizLanguage string   `json:"quizLanguage"`
+	DisplayColor string   `json:"displayColor"`
+	DisplayHex   string   `json:"displayHex"`
+	Progress     int      `json:"progress"`
+	WrongCount   int      `json:"wrongCount"`
+	TotalQuiz    int      `json:"totalQuiz"`
+	IsFinished   bool     `json:"isFinished"`
 }
 
 type Player struct {
-	ID      string          `json:"id"`
-	Name    string          `json:"name"`
-	IsHost  bool            `json:"is_host"`
-	IsReady bool            `json:"is_ready"`
-	Score   int             `json:"score"`
-	Conn    *websocket.Conn `json:"-"`
-	Game    *Game           `json:"game"`
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	IsHost   bool   `json:"is_host"`
+	IsReady  bool   `json:"is_ready"`
+	Language string `json:"language"`
+	Score    int    `json:"score"`
+	Conn     Conn   `json:"-"`
+	Game     *Game  `json:"game"`
+	// 觀戰者只接收房間消息，不參與作答與排名
+	IsSpectator bool `json:"is_spectator"`
+	// 由伺服器操作的機器人玩家
+	IsBot bool `json:"is_bot"`
+	// 不列入排名與比賽結算的玩家（房主加入機器人時選擇）
+	unranked bool
+	// 協商後的協定版本與啟用的功能，0 表示未協商（視為舊版協定）
+	protocol     int
+	capabilities map[string]bool
+	// 是否已收到完整的玩家列表，之後才能改發差異更新；由 r.mu 保護
+	hasPlayerList bool
+	// 連線的速率限制狀態
+	limiter *rateLimiter
+	// 尚未進入房間（排隊中）時處理過的請求結果，用於重送請求的去重
+	requests requestCache
+	// 房間發放的重新連線憑證，由 r.mu 保護
+	session string
+	// 客戶端在 hello 中宣告的介面語系，用於錯誤訊息；未宣告時使用題目語言
+	locale string
+	// 同一條連線不允許同時寫入，協定版本與語系亦由此鎖保護
+	sendMu sync.Mutex
 }
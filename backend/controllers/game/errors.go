@@ -0,0 +1,169 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rejxcy/logger"
+)
+
+// 錯誤訊息預設使用的語系
+const DefaultErrorLocale = LocaleZhTW
+
+// GameError 帶有固定錯誤碼與參數的錯誤，訊息依客戶端語系由訊息目錄產生
+type GameError struct {
+	Code   string
+	Params map[string]interface{}
+}
+
+// NewError 建立指定錯誤碼的錯誤
+func NewError(code string) *GameError {
+	return &GameError{Code: code}
+}
+
+// With 返回帶有額外參數的錯誤副本，參數會代入訊息中的 {key}
+func (e *GameError) With(key string, value interface{}) *GameError {
+	params := make(map[string]interface{}, len(e.Params)+1)
+	for k, v := range e.Params {
+		params[k] = v
+	}
+	params[key] = value
+	return &GameError{Code: e.Code, Params: params}
+}
+
+// Error 返回錯誤碼，REST 回應與日誌皆以錯誤碼呈現
+func (e *GameError) Error() string {
+	return e.Code
+}
+
+// Is 錯誤碼相同即視為同一種錯誤，不比較參數
+func (e *GameError) Is(target error) bool {
+	t, ok := target.(*GameError)
+	return ok && t.Code == e.Code
+}
+
+// Message 依語系產生錯誤訊息，找不到語系時使用預設語系，找不到錯誤碼時返回錯誤碼本身
+func (e *GameError) Message(locale string) string {
+	template, ok := errorCatalogs[locale][e.Code]
+	if !ok {
+		template, ok = errorCatalogs[DefaultErrorLocale][e.Code]
+	}
+	if !ok {
+		return e.Code
+	}
+	for k, v := range e.Params {
+		template = strings.ReplaceAll(template, "{"+k+"}", fmt.Sprint(v))
+	}
+	return template
+}
+
+// AsGameError 將任意錯誤轉為 GameError；非 GameError 的錯誤視為內部錯誤，原始內容只記錄在伺服器日誌，不發送給客戶端
+func AsGameError(err error) *GameError {
+	var gerr *GameError
+	if errors.As(err, &gerr) {
+		return gerr
+	}
+	logger.Output.Error("Internal error: %v", err)
+	return NewError(ErrCodeInternal)
+}
+
+// 各語系的錯誤訊息目錄，每個錯誤碼在每個語系都必須有訊息
+var errorCatalogs = map[string]map[string]string{
+	LocaleZhTW: {
+		ErrCodeRoomFull:             "房間已滿",
+		ErrCodeRoomNotFound:         "找不到房間",
+		ErrCodeRoomExists:           "房間已存在",
+		ErrCodeNotHost:              "只有房主可以執行此操作",
+		ErrCodeGameNotStarted:       "遊戲尚未開始",
+		ErrCodeGameInProgress:       "遊戲進行中",
+		ErrCodeGameFinished:         "遊戲已結束",
+		ErrCodeGameNotFinished:      "遊戲尚未結束",
+		ErrCodeNotReady:             "玩家不足或部分玩家尚未準備",
+		ErrCodeNotEnoughPlayers:     "玩家人數不足",
+		ErrCodeInvalidAnswer:        "無效的顏色選擇",
+		ErrCodePlayerNotFound:       "找不到玩家",
+		ErrCodeHostCannotReady:      "房主無需設置準備狀態",
+		ErrCodeHostCannotChallenge:  "房主無法建立挑戰",
+		ErrCodeSpectator:            "觀戰中無法作答",
+		ErrCodeInvalidMessage:       "無效的消息格式",
+		ErrCodeInvalidPayload:       "{type} 的內容格式錯誤：{detail}",
+		ErrCodeUnknownMessage:       "未知的消息類型：{type}",
+		ErrCodeIncompatibleProtocol: "不支援的協定版本 {version}，伺服器支援 {min} 至 {max}",
+		ErrCodeInvalidSettings:      "無效的房間設定",
+		ErrCodeInvalidPalette:       "無效的調色盤",
+		ErrCodeDailyAlreadyPlayed:   "今天已經挑戰過了",
+		ErrCodeDailyExpired:         "每日挑戰已過期",
+		ErrCodeChallengeNotFound:    "找不到挑戰或挑戰已過期",
+		ErrCodeTournamentNotFound:   "找不到錦標賽",
+		ErrCodeTournamentStarted:    "錦標賽已開始",
+		ErrCodeAlreadyRegistered:    "已經報名過了",
+		ErrCodeNotRegistered:        "未報名此分組賽",
+		ErrCodeHeatNotFound:         "找不到分組賽",
+		ErrCodeInQueue:              "配對中，請等待配對完成",
+		ErrCodeConnectionClosed:     "連線已關閉",
+		ErrCodeInternal:             "伺服器發生錯誤",
+		ErrCodeRateLimited:          "{type} 發送過於頻繁，請稍後再試，持續發送將被斷線",
+		ErrCodeInvalidBotConfig:     "無效的機器人設定",
+	},
+	LocaleEn: {
+		ErrCodeRoomFull:             "The room is full",
+		ErrCodeRoomNotFound:         "Room not found",
+		ErrCodeRoomExists:           "The room already exists",
+		ErrCodeNotHost:              "Only the host can do this",
+		ErrCodeGameNotStarted:       "The game has not started",
+		ErrCodeGameInProgress:       "A game is in progress",
+		ErrCodeGameFinished:         "The game is over",
+		ErrCodeGameNotFinished:      "The game has not finished",
+		ErrCodeNotReady:             "Not enough players, or some players are not ready",
+		ErrCodeNotEnoughPlayers:     "Not enough players",
+		ErrCodeInvalidAnswer:        "Invalid color choice",
+		ErrCodePlayerNotFound:       "Player not found",
+		ErrCodeHostCannotReady:      "The host does not need to get ready",
+		ErrCodeHostCannotChallenge:  "The host cannot create a challenge",
+		ErrCodeSpectator:            "Spectators cannot answer",
+		ErrCodeInvalidMessage:       "Malformed message",
+		ErrCodeInvalidPayload:       "Invalid payload for {type}: {detail}",
+		ErrCodeUnknownMessage:       "Unknown message type: {type}",
+		ErrCodeIncompatibleProtocol: "Protocol version {version} is not supported; the server supports {min} to {max}",
+		ErrCodeInvalidSettings:      "Invalid room settings",
+		ErrCodeInvalidPalette:       "Invalid palette",
+		ErrCodeDailyAlreadyPlayed:   "You have already played today's challenge",
+		ErrCodeDailyExpired:         "The daily challenge has expired",
+		ErrCodeChallengeNotFound:    "Challenge not found or expired",
+		ErrCodeTournamentNotFound:   "Tournament not found",
+		ErrCodeTournamentStarted:    "The tournament has already started",
+		ErrCodeAlreadyRegistered:    "Already registered",
+		ErrCodeNotRegistered:        "Not registered for this heat",
+		ErrCodeHeatNotFound:         "Heat not found",
+		ErrCodeInQueue:              "Matchmaking in progress, please wait",
+		ErrCodeConnectionClosed:     "The connection is closed",
+		ErrCodeInternal:             "Internal server error",
+		ErrCodeRateLimited:          "Too many {type} messages; slow down or you will be disconnected",
+		ErrCodeInvalidBotConfig:     "Invalid bot settings",
+	},
+}
+
+// ErrorCodes 返回所有已登記的錯誤碼
+func ErrorCodes() []string {
+	codes := make([]string, 0, len(errorCatalogs[DefaultErrorLocale]))
+	for code := range errorCatalogs[DefaultErrorLocale] {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// NewErrorMessage 建立統一格式的錯誤消息，訊息使用指定語系
+func NewErrorMessage(err error, locale string) Message {
+	gerr := AsGameError(err)
+	return Message{
+		Type: MsgTypeError,
+		Payload: ErrorPayload{
+			Code:    gerr.Code,
+			Params:  gerr.Params,
+			Message: gerr.Message(locale),
+		},
+	}
+}
@@ -0,0 +1,69 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorCatalogsAreComplete(t *testing.T) {
+	for locale, catalog := range errorCatalogs {
+		for _, code := range ErrorCodes() {
+			if catalog[code] == "" {
+				t.Errorf("locale %s has no message for %s", locale, code)
+			}
+		}
+		if len(catalog) != len(ErrorCodes()) {
+			t.Errorf("locale %s has %d messages, want %d", locale, len(catalog), len(ErrorCodes()))
+		}
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    *GameError
+		locale string
+		want   string
+	}{
+		{name: "requested locale", err: NewError(ErrCodeRoomFull), locale: LocaleEn, want: errorCatalogs[LocaleEn][ErrCodeRoomFull]},
+		{name: "unknown locale uses default", err: NewError(ErrCodeRoomFull), locale: "fr", want: errorCatalogs[DefaultErrorLocale][ErrCodeRoomFull]},
+		{name: "unknown code", err: NewError("mystery"), locale: LocaleEn, want: "mystery"},
+		{name: "params", err: NewError(ErrCodeRateLimited).With("type", "answer"), locale: LocaleEn, want: "Too many answer messages; slow down or you will be disconnected"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Message(tt.locale); got != tt.want {
+				t.Fatalf("message = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGameErrorIs(t *testing.T) {
+	base := NewError(ErrCodeInvalidPayload)
+	withParams := base.With("type", "ready")
+	if base.Params != nil {
+		t.Fatal("With modified the original error")
+	}
+	if !errors.Is(withParams, NewError(ErrCodeInvalidPayload)) {
+		t.Fatal("errors with the same code but different params are not equal")
+	}
+	if errors.Is(withParams, NewError(ErrCodeInvalidMessage)) {
+		t.Fatal("errors with different codes are equal")
+	}
+}
+
+func TestAsGameError(t *testing.T) {
+	wrapped := fmt.Errorf("join: %w", NewError(ErrCodeRoomFull))
+	if got := AsGameError(wrapped); got.Code != ErrCodeRoomFull {
+		t.Fatalf("wrapped error code = %s, want %s", got.Code, ErrCodeRoomFull)
+	}
+
+	// 非 GameError 的內容不發送給客戶端
+	msg := NewErrorMessage(errors.New("database password is hunter2"), LocaleEn)
+	payload := msg.Payload.(ErrorPayload)
+	if payload.Code != ErrCodeInternal || payload.Message != errorCatalogs[LocaleEn][ErrCodeInternal] {
+		t.Fatalf("payload = %+v, want the internal error", payload)
+	}
+}
@@ -1,37 +1,37 @@
 package game
 
 import (
-	"errors"
 	"math/rand"
 	"time"
 )
 
 var (
-	ErrGameFinished   = errors.New("遊戲已結束")
-	ErrInvalidColor   = errors.New("無效的顏色選擇")
-	ErrGameNotStarted = errors.New("遊戲尚未開始")
+	ErrGameFinished   = NewError(ErrCodeGameFinished)
+	ErrInvalidColor   = NewError(ErrCodeInvalidAnswer)
+	ErrGameNotStarted = NewError(ErrCodeGameNotStarted)
 )
 
-// 可用的顏色列表
-var ValidColors = []string{"red", "green", "blue", "yellow", "orange", "purple"}
+// 每次產生題目的批次大小；計時模式會在題目用完前再補一批
+const quizChunkSize = 20
 
-// 使用 map 優化顏色驗證
-var validColorMap = make(map[string]bool)
-
-func init() {
-	for _, color := range ValidColors {
-		validColorMap[color] = true
-	}
+// NewGame 依房間設定（調色盤、模式、題數）創建並初始化一個新遊戲
+func NewGame(settings RoomSettings) *Game {
+	return NewGameWithSeed(settings, time.Now().UnixNano())
 }
 
-// NewGame 創建並初始化一個新遊戲
-func NewGame() *Game {
+// NewGameWithSeed 以指定種子創建遊戲，相同設定與種子會產生相同的題目序列
+func NewGameWithSeed(settings RoomSettings, seed int64) *Game {
 	game := &Game{
-		QuizList:   make([]string, QuizCount),
-		ColorList:  make([]string, QuizCount),
-		TotalQuiz:  QuizCount,
+		Mode:       settings.Mode,
+		Seed:       seed,
 		WrongCount: 0,
 		IsFinished: false,
+		palette:    settings.Palette,
+	}
+	if game.Mode == GameModeTimed {
+		game.TimeLimit = settings.TimeLimit
+	} else {
+		game.TotalQuiz = settings.QuizCount
 	}
 	game.generateColors()
 	return game
@@ -41,59 +41,165 @@ func NewGame() *Game {
 func (g *Game) GetStatus() (GameStatus, error) {
 	if g.IsFinished {
 		return GameStatus{
-			Progress:   QuizCount,
+			Mode:       g.Mode,
+			Progress:   g.Progress,
 			WrongCount: g.WrongCount,
 			IsFinished: true,
-			TotalQuiz:  QuizCount,
+			TotalQuiz:  g.TotalQuiz,
+			EndsAt:     g.endsAt(),
 		}, nil
 	}
 
+	language := g.quizLanguage(g.Progress)
 	return GameStatus{
-		Quiz:         g.QuizList[g.Progress],
+		Mode:         g.Mode,
+		Quiz:         g.palette.Word(g.QuizList[g.Progress], language),
+		QuizLanguage: language,
 		DisplayColor: g.ColorList[g.Progress],
+		DisplayHex:   g.palette.Hex(g.ColorList[g.Progress]),
 		Progress:     g.Progress,
 		WrongCount:   g.WrongCount,
 		IsFinished:   g.IsFinished,
-		TotalQuiz:    QuizCount,
+		TotalQuiz:    g.TotalQuiz,
+		EndsAt:       g.endsAt(),
 	}, nil
 }
 
+// SetLanguages 設定題目文字使用的語言，多個語言時依題號輪替
+func (g *Game) SetLanguages(languages []string) {
+	g.languages = languages
+}
+
+// 取得指定題號使用的語言
+func (g *Game) quizLanguage(index int) string {
+	if len(g.languages) == 0 {
+		return DefaultQuizLanguage
+	}
+	return g.languages[index%len(g.languages)]
+}
+
+// Clone 複製一份題目相同、進度獨立的遊戲（共用題目模式使用）
+func (g *Game) Clone() *Game {
+	clone := *g
+	clone.QuizList = append([]string(nil), g.QuizList...)
+	clone.ColorList = append([]string(nil), g.ColorList...)
+	clone.QuestionTimesMs = append([]int64(nil), g.QuestionTimesMs...)
+	return &clone
+}
+
+// Start 記錄遊戲開始時間，計時模式同時設定截止時間
+func (g *Game) Start(now time.Time) {
+	g.StartedAt = now
+	g.QuestionStartedAt = now
+	if g.Mode == GameModeTimed {
+		g.Deadline = now.Add(time.Duration(g.TimeLimit) * time.Second)
+	}
+}
+
 // 判斷答案是否正確，並更新遊戲狀態
 func (g *Game) Answer(color string) (bool, error) {
 	if g.IsFinished {
 		return false, ErrGameFinished
 	}
+	if g.Expired(time.Now()) {
+		g.Finish(g.Deadline)
+		return false, ErrGameFinished
+	}
 
-	if !isValidColor(color) {
+	if !g.palette.Has(color) {
 		return false, ErrInvalidColor
 	}
 
-	correct := color == g.QuizList[g.Progress]
+	return color == g.QuizList[g.Progress], nil
+}
+
+// 依作答結果推進進度；一般模式答完所有題目即結束，計時模式則持續補充題目
+func (g *Game) advance(correct bool) {
 	if correct {
-		if g.Progress >= QuizCount {
-			g.IsFinished = true
+		now := time.Now()
+		if !g.QuestionStartedAt.IsZero() {
+			g.QuestionTimesMs = append(g.QuestionTimesMs, now.Sub(g.QuestionStartedAt).Milliseconds())
 		}
+		g.QuestionStartedAt = now
+		g.Progress++
+	} else {
+		g.WrongCount++
 	}
-	return correct, nil
+
+	if g.Mode == GameModeTimed {
+		if g.Progress >= len(g.QuizList) {
+			g.generateColors()
+		}
+		return
+	}
+	if g.Progress >= g.TotalQuiz {
+		g.Finish(time.Now())
+	}
+}
+
+// Expired 判斷計時模式是否已超過截止時間
+func (g *Game) Expired(now time.Time) bool {
+	return !g.Deadline.IsZero() && !now.Before(g.Deadline)
+}
+
+// Finish 結束遊戲並記錄結束時間
+func (g *Game) Finish(now time.Time) {
+	if g.IsFinished {
+		return
+	}
+	g.IsFinished = true
+	g.FinishedAt = now
+}
+
+// Duration 返回遊戲進行的時間
+func (g *Game) Duration() time.Duration {
+	if g.StartedAt.IsZero() || g.FinishedAt.IsZero() {
+		return 0
+	}
+	return g.FinishedAt.Sub(g.StartedAt)
+}
+
+// 計時模式的截止時間（Unix 毫秒），一般模式為 0
+func (g *Game) endsAt() int64 {
+	if g.Deadline.IsZero() {
+		return 0
+	}
+	return g.Deadline.UnixMilli()
 }
 
 // 重置遊戲狀態
 func (g *Game) Restart() {
+	g.Seed = time.Now().UnixNano()
+	g.QuizList = nil
+	g.ColorList = nil
 	g.generateColors()
 	g.Progress = 0
 	g.WrongCount = 0
 	g.IsFinished = false
+	g.StartedAt = time.Time{}
+	g.FinishedAt = time.Time{}
+	g.Deadline = time.Time{}
+	g.QuestionTimesMs = nil
+	g.QuestionStartedAt = time.Time{}
 }
 
-// 產生新的題目與顏色列表
+// 產生下一批題目與顏色；每批以種子與批次序號決定，相同種子必得相同題目
 func (g *Game) generateColors() {
-	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
-	for i := 0; i < QuizCount; i++ {
-		g.QuizList[i] = ValidColors[rng.Intn(len(ValidColors))]
-		g.ColorList[i] = ValidColors[rng.Intn(len(ValidColors))]
+	keys := g.palette.Keys()
+	for len(g.QuizList) < g.TotalQuiz || g.Mode == GameModeTimed {
+		chunk := int64(len(g.QuizList) / quizChunkSize)
+		rng := rand.New(rand.NewSource(g.Seed + chunk))
+		for i := 0; i < quizChunkSize; i++ {
+			g.QuizList = append(g.QuizList, keys[rng.Intn(len(keys))])
+			g.ColorList = append(g.ColorList, keys[rng.Intn(len(keys))])
+		}
+		if g.Mode == GameModeTimed {
+			break
+		}
 	}
-}
 
-func isValidColor(color string) bool {
-	return validColorMap[color]
+	if g.Mode != GameModeTimed {
+		g.QuizList = g.QuizList[:g.TotalQuiz]
+		g.ColorList = g.ColorList[:g.TotalQuiz]
+	}
 }
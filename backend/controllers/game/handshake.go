@@ -0,0 +1,160 @@
+package game
+
+import (
+	"sort"
+)
+
+// 協定版本：版本 1 為舊版前端使用的消息名稱，舊版前端不會送出 hello，未協商的連線視為版本 1
+const (
+	ProtocolVersion    = 2
+	MinProtocolVersion = 1
+	legacyProtocol     = 1
+)
+
+// 協定錯誤碼
+const (
+	ErrCodeIncompatibleProtocol = "incompatible_protocol"
+)
+
+// 舊版協定的消息名稱
+const (
+	legacyMsgTypeRestart = "restart"
+)
+
+// 伺服器支援的功能，客戶端在 hello 中宣告自己支援的功能後取交集啟用
+var serverFeatures = []string{
+	"score_events",
+	"matches",
+	"palettes",
+	"quiz_languages",
+	"challenges",
+	"ready_check",
+	"late_join",
+	"auto_host",
+	"sync",
+	FeaturePlayerListDelta,
+}
+
+// HelloPayload 客戶端連線後宣告的協定版本與支援的功能
+type HelloPayload struct {
+	Version      int      `json:"version"`
+	Capabilities []string `json:"capabilities"`
+	Locale       string   `json:"locale,omitempty"`
+}
+
+// WelcomePayload 伺服器回應協商後的版本、雙方皆支援的功能與目前房間設定
+type WelcomePayload struct {
+	Version       int           `json:"version"`
+	ServerVersion int           `json:"serverVersion"`
+	MinVersion    int           `json:"minVersion"`
+	Features      []string      `json:"features"`
+	PlayerID      string        `json:"playerId"`
+	Settings      *RoomSettings `json:"settings,omitempty"`
+	// 重新連線憑證，斷線後以 session 參數帶回即可恢復原本的玩家身分
+	SessionToken string `json:"sessionToken,omitempty"`
+}
+
+// 依版本轉換客戶端消息，讓舊版客戶端可以繼續使用
+type protocolAdapter interface {
+	// 將舊版客戶端送來的消息轉為目前的格式
+	inbound(env *Envelope)
+}
+
+// 各版本的轉換器，目前版本不需要轉換
+var protocolAdapters = map[int]protocolAdapter{
+	legacyProtocol: legacyAdapter{},
+}
+
+// 版本 1：舊版前端以 restart 要求重新開始，對應目前的 game_reset
+// 伺服器消息沿用舊版伺服器的名稱（包含 game_end、game_reset），不需要轉換；舊版前端會忽略不認得的消息類型
+type legacyAdapter struct{}
+
+func (legacyAdapter) inbound(env *Envelope) {
+	if env.Type == legacyMsgTypeRestart {
+		env.Type = MsgTypeGameReset
+	}
+}
+
+// negotiate 依客戶端的 hello 決定連線使用的協定版本與功能，版本過舊時返回錯誤
+func (p *Player) negotiate(hello HelloPayload) (WelcomePayload, error) {
+	version := hello.Version
+	if version > ProtocolVersion {
+		version = ProtocolVersion
+	}
+	if version < MinProtocolVersion {
+		return WelcomePayload{}, NewError(ErrCodeIncompatibleProtocol).
+			With("version", hello.Version).
+			With("min", MinProtocolVersion).
+			With("max", ProtocolVersion)
+	}
+
+	requested := make(map[string]bool, len(hello.Capabilities))
+	for _, c := range hello.Capabilities {
+		requested[c] = true
+	}
+	capabilities := make(map[string]bool)
+	features := make([]string, 0, len(serverFeatures))
+	for _, f := range serverFeatures {
+		if requested[f] {
+			capabilities[f] = true
+			features = append(features, f)
+		}
+	}
+	sort.Strings(features)
+
+	p.sendMu.Lock()
+	p.protocol = version
+	p.capabilities = capabilities
+	if IsSupportedLocale(hello.Locale) {
+		p.locale = hello.Locale
+	}
+	p.sendMu.Unlock()
+
+	return WelcomePayload{
+		Version:       version,
+		ServerVersion: ProtocolVersion,
+		MinVersion:    MinProtocolVersion,
+		Features:      features,
+		PlayerID:      p.ID,
+	}, nil
+}
+
+// HasCapability 判斷連線是否已協商啟用指定功能
+func (p *Player) HasCapability(feature string) bool {
+	p.sendMu.Lock()
+	defer p.sendMu.Unlock()
+	return p.capabilities[feature]
+}
+
+// handleHello 協商協定版本並回覆 welcome；不相容的客戶端會收到錯誤並被斷線
+func (p *Player) handleHello(hello HelloPayload, room *Room) error {
+	welcome, err := p.negotiate(hello)
+	if err != nil {
+		p.SendError(err)
+		p.Close()
+		return nil
+	}
+	if room != nil {
+		room.mu.Lock()
+		settings := room.Settings
+		welcome.SessionToken = p.session
+		room.mu.Unlock()
+		welcome.Settings = &settings
+	}
+	return p.Send(Message{Type: MsgTypeWelcome, Payload: welcome})
+}
+
+// 連線使用的協定版本，尚未協商時視為舊版，由該連線的讀取循環呼叫
+func (p *Player) protocolVersion() int {
+	if p.protocol == 0 {
+		return legacyProtocol
+	}
+	return p.protocol
+}
+
+// 依連線的協定版本轉換收到的消息，呼叫端為該連線的讀取循環
+func (p *Player) adaptInbound(env *Envelope) {
+	if adapter, ok := protocolAdapters[p.protocolVersion()]; ok {
+		adapter.inbound(env)
+	}
+}
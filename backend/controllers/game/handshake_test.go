@@ -0,0 +1,103 @@
+package game
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name         string
+		hello        HelloPayload
+		wantVersion  int
+		wantFeatures []string
+		wantLocale   string
+		wantErr      bool
+	}{
+		{
+			name:         "current version",
+			hello:        HelloPayload{Version: ProtocolVersion, Capabilities: []string{FeaturePlayerListDelta, "unknown"}, Locale: "en"},
+			wantVersion:  ProtocolVersion,
+			wantFeatures: []string{FeaturePlayerListDelta},
+			wantLocale:   "en",
+		},
+		{
+			name:         "newer client falls back to server version",
+			hello:        HelloPayload{Version: ProtocolVersion + 1},
+			wantVersion:  ProtocolVersion,
+			wantFeatures: []string{},
+		},
+		{
+			name:         "legacy version",
+			hello:        HelloPayload{Version: legacyProtocol, Locale: "fr"},
+			wantVersion:  legacyProtocol,
+			wantFeatures: []string{},
+		},
+		{name: "too old", hello: HelloPayload{Version: MinProtocolVersion - 1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPlayer(NewMemoryConn("alice"), "alice", false)
+			welcome, err := p.negotiate(tt.hello)
+			if tt.wantErr {
+				if !errors.Is(err, NewError(ErrCodeIncompatibleProtocol)) {
+					t.Fatalf("err = %v, want %s", err, ErrCodeIncompatibleProtocol)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if welcome.Version != tt.wantVersion || p.protocol != tt.wantVersion {
+				t.Fatalf("version = %d (player %d), want %d", welcome.Version, p.protocol, tt.wantVersion)
+			}
+			if !reflect.DeepEqual(welcome.Features, tt.wantFeatures) {
+				t.Fatalf("features = %v, want %v", welcome.Features, tt.wantFeatures)
+			}
+			if p.locale != tt.wantLocale {
+				t.Fatalf("locale = %q, want %q", p.locale, tt.wantLocale)
+			}
+		})
+	}
+}
+
+func TestHelloAllowsUnknownFields(t *testing.T) {
+	payload, err := decodeEnvelopePayload(Envelope{Type: MsgTypeHello, Payload: []byte(`{"version":2,"capabilities":[],"client":"test"}`)})
+	if err != nil {
+		t.Fatalf("decode hello: %v", err)
+	}
+	if hello := payload.(*HelloPayload); hello.Version != 2 {
+		t.Fatalf("version = %d, want 2", hello.Version)
+	}
+
+	// 其他消息仍嚴格拒絕未知欄位
+	_, err = decodeEnvelopePayload(Envelope{Type: MsgTypeAddBots, Payload: []byte(`{"count":1,"difficulty":"easy","extra":1}`)})
+	if !errors.Is(err, NewError(ErrCodeInvalidPayload)) {
+		t.Fatalf("err = %v, want %s", err, ErrCodeInvalidPayload)
+	}
+}
+
+func TestLegacyClientWithoutHello(t *testing.T) {
+	room := NewRoom("legacy")
+	host := NewPlayer(NewMemoryConn("host"), "host", true)
+	if err := room.AddPlayer(host); err != nil {
+		t.Fatalf("add host: %v", err)
+	}
+	_, conn := joinTestPlayer(t, room, "alice")
+
+	// 未送出 hello 的連線收到與舊版伺服器相同名稱的消息，新增的消息類型照常發送
+	room.Broadcast(Message{Type: MsgTypeRoomSettings, Payload: room.Settings})
+	room.Broadcast(Message{Type: MsgTypeGameEnd, Payload: NoticePayload("遊戲結束")})
+	types := messageTypes(drainMessages(t, conn))
+	if types[MsgTypeGameEnd] != 1 || types[MsgTypeRoomSettings] != 1 {
+		t.Fatalf("got %v, want game_end and room_settings", types)
+	}
+
+	// 舊版的 restart 對應 game_reset
+	deliver(t, room, host, `{"type":"restart"}`)
+	if types := messageTypes(drainMessages(t, conn)); types[MsgTypeGameReset] != 1 {
+		t.Fatalf("restart: got %v, want game_reset", types)
+	}
+}
@@ -0,0 +1,72 @@
+package game
+
+import (
+	"time"
+
+	"github.com/rejxcy/logger"
+)
+
+// 遊戲進行中加入的處理方式
+const (
+	LateJoinReject   = "reject"
+	LateJoinSpectate = "spectate"
+	LateJoinPlay     = "play"
+)
+
+// 檢查中途加入設定，未設定時視為觀戰
+func (s RoomSettings) validateLateJoin() error {
+	switch s.LateJoin {
+	case "", LateJoinReject, LateJoinSpectate, LateJoinPlay:
+		return nil
+	}
+	return NewError(ErrCodeInvalidSettings)
+}
+
+// 房間的中途加入處理方式
+func (s RoomSettings) lateJoinPolicy() string {
+	if s.LateJoin == "" {
+		return LateJoinSpectate
+	}
+	return s.LateJoin
+}
+
+// 依房間設定處理遊戲進行中加入的玩家，呼叫時需持有 r.mu
+// 以玩家身分加入時會拿到本回合的題目（共用題目時與其他玩家相同），計時模式只剩下本回合的剩餘時間
+func (r *Room) lateJoinLocked(p *Player, now time.Time) error {
+	switch r.Settings.lateJoinPolicy() {
+	case LateJoinReject:
+		return NewError(ErrCodeGameInProgress)
+	case LateJoinSpectate:
+		p.IsSpectator = true
+		return nil
+	}
+
+	if r.Settings.SharedQuiz || r.Seed != 0 {
+		p.Game = NewGameWithSeed(r.Settings, r.roundSeed)
+	} else {
+		p.Game = NewGame(r.Settings)
+	}
+	p.Game.SetLanguages(r.quizLanguages(p))
+	p.Game.Start(now)
+	if p.Game.Mode == GameModeTimed {
+		p.Game.Deadline = r.roundStartedAt.Add(time.Duration(r.Settings.TimeLimit) * time.Second)
+	}
+	p.IsReady = true
+	p.Score = 0
+	return nil
+}
+
+// SendLateJoinState 遊戲進行中加入的玩家立即收到目前的遊戲狀態與開始通知
+func (r *Room) SendLateJoinState(p *Player) {
+	r.mu.Lock()
+	playing := r.Status == RoomStatusPlaying && p.isCompetitor()
+	r.mu.Unlock()
+	if !playing {
+		return
+	}
+
+	r.sendGameState(p)
+	if err := p.Send(Message{Type: MsgTypeGameStart, Payload: NoticePayload("遊戲開始")}); err != nil {
+		logger.Output.Error("發送遊戲開始訊息給 %s 失敗: %v", p.Name, err)
+	}
+}
@@ -0,0 +1,81 @@
+package game
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLateJoin(t *testing.T) {
+	tests := []struct {
+		policy        string
+		wantErr       string
+		wantSpectator bool
+		wantPlaying   bool
+	}{
+		{policy: LateJoinReject, wantErr: ErrCodeGameInProgress},
+		{policy: LateJoinSpectate, wantSpectator: true},
+		{policy: "", wantSpectator: true},
+		{policy: LateJoinPlay, wantPlaying: true},
+	}
+
+	for _, tt := range tests {
+		t.Run("policy "+tt.policy, func(t *testing.T) {
+			room := NewRoom("late")
+			room.Settings.LateJoin = tt.policy
+			room.Settings.SharedQuiz = true
+			first, _ := joinTestPlayer(t, room, "alice")
+			if err := room.ForceStart(); err != nil {
+				t.Fatalf("start game: %v", err)
+			}
+
+			conn := NewMemoryConn("bob")
+			late := NewPlayer(conn, "bob", false)
+			err := room.AddPlayer(late)
+			if tt.wantErr != "" {
+				if !errors.Is(err, NewError(tt.wantErr)) {
+					t.Fatalf("err = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("late join: %v", err)
+			}
+			room.SendLateJoinState(late)
+
+			types := messageTypes(drainMessages(t, conn))
+			if late.IsSpectator != tt.wantSpectator || (types[MsgTypeGameStart] == 1) != tt.wantPlaying {
+				t.Fatalf("spectator %v, messages %v; want spectator %v, playing %v", late.IsSpectator, types, tt.wantSpectator, tt.wantPlaying)
+			}
+			// 共用題目的房間中途加入的玩家拿到相同的題目
+			if tt.wantPlaying && !reflect.DeepEqual(late.Game.QuizList, first.Game.QuizList) {
+				t.Fatal("late player got different quizzes in a shared-quiz room")
+			}
+		})
+	}
+}
+
+func TestLateJoinTimedKeepsRoundDeadline(t *testing.T) {
+	room := NewRoom("late")
+	room.Settings.Mode = GameModeTimed
+	room.Settings.LateJoin = LateJoinPlay
+	joinTestPlayer(t, room, "alice")
+	if err := room.ForceStart(); err != nil {
+		t.Fatalf("start game: %v", err)
+	}
+	defer room.Close()
+
+	room.mu.Lock()
+	late := NewPlayer(NewMemoryConn("bob"), "bob", false)
+	err := room.lateJoinLocked(late, room.roundStartedAt.Add(20*time.Second))
+	want := room.roundStartedAt.Add(time.Duration(room.Settings.TimeLimit) * time.Second)
+	room.mu.Unlock()
+	if err != nil {
+		t.Fatalf("late join: %v", err)
+	}
+	// 計時模式只剩本回合的剩餘時間
+	if !late.Game.Deadline.Equal(want) {
+		t.Fatalf("deadline = %v, want the round deadline %v", late.Game.Deadline, want)
+	}
+}
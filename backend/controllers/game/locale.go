@@ -0,0 +1,91 @@
+package game
+
+import (
+	"unicode/utf8"
+)
+
+// 支援的題目語言
+const (
+	LocaleEn   = "en"
+	LocaleZhTW = "zh-TW"
+
+	DefaultQuizLanguage = LocaleEn
+	maxWordLength       = 16
+)
+
+var supportedLocales = map[string]bool{
+	LocaleEn:   true,
+	LocaleZhTW: true,
+}
+
+// IsSupportedLocale 判斷是否為支援的題目語言
+func IsSupportedLocale(locale string) bool {
+	return supportedLocales[locale]
+}
+
+// Word 取得顏色在指定語言下的文字，找不到時依序退回英文、顯示名稱與鍵值
+func (c PaletteColor) Word(locale string) string {
+	if word, ok := c.Words[locale]; ok {
+		return word
+	}
+	if word, ok := c.Words[LocaleEn]; ok {
+		return word
+	}
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Key
+}
+
+// Word 依顏色鍵值取得指定語言的文字
+func (p Palette) Word(key string, locale string) string {
+	for _, c := range p.Colors {
+		if c.Key == key {
+			return c.Word(locale)
+		}
+	}
+	return key
+}
+
+// 驗證顏色的多語系文字
+func (c PaletteColor) validateWords() error {
+	for locale, word := range c.Words {
+		length := utf8.RuneCountInString(word)
+		if !IsSupportedLocale(locale) || length == 0 || length > maxWordLength {
+			return NewError(ErrCodeInvalidPalette)
+		}
+	}
+	return nil
+}
+
+// 驗證房間的題目語言設定，第二語言留空表示不啟用雙語模式
+func (s RoomSettings) validateLanguages() error {
+	if !IsSupportedLocale(s.QuizLanguage) {
+		return NewError(ErrCodeInvalidSettings)
+	}
+	if s.SecondaryLanguage != "" && !IsSupportedLocale(s.SecondaryLanguage) {
+		return NewError(ErrCodeInvalidSettings)
+	}
+	return nil
+}
+
+// 決定玩家的題目語言順序：玩家自選語言優先於房間語言，雙語模式下與第二語言交替出題
+func (r *Room) quizLanguages(p *Player) []string {
+	primary := r.Settings.QuizLanguage
+	if p.Language != "" {
+		primary = p.Language
+	}
+	if r.Settings.SecondaryLanguage == "" || r.Settings.SecondaryLanguage == primary {
+		return []string{primary}
+	}
+	return []string{primary, r.Settings.SecondaryLanguage}
+}
+
+// SetLanguage 設定玩家個人的題目語言，空字串表示使用房間設定
+func (p *Player) SetLanguage(locale string) error {
+	if locale != "" && !IsSupportedLocale(locale) {
+		return NewError(ErrCodeInvalidSettings)
+	}
+	p.Language = locale
+	return nil
+}
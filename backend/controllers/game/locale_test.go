@@ -0,0 +1,112 @@
+package game
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPaletteColorWord(t *testing.T) {
+	tests := []struct {
+		name   string
+		color  PaletteColor
+		locale string
+		want   string
+	}{
+		{name: "requested locale", color: PaletteColor{Key: "red", Name: "紅", Words: words("red", "紅")}, locale: LocaleZhTW, want: "紅"},
+		{name: "falls back to english", color: PaletteColor{Key: "red", Name: "紅", Words: map[string]string{LocaleEn: "red"}}, locale: LocaleZhTW, want: "red"},
+		{name: "falls back to display name", color: PaletteColor{Key: "red", Name: "紅"}, locale: LocaleEn, want: "紅"},
+		{name: "falls back to key", color: PaletteColor{Key: "red"}, locale: LocaleEn, want: "red"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.color.Word(tt.locale); got != tt.want {
+				t.Fatalf("Word(%s) = %q, want %q", tt.locale, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaletteWordsValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		words   map[string]string
+		wantErr bool
+	}{
+		{name: "supported locales", words: words("red", "紅")},
+		{name: "unsupported locale", words: map[string]string{"fr": "rouge"}, wantErr: true},
+		{name: "empty word", words: map[string]string{LocaleEn: ""}, wantErr: true},
+		{name: "word too long", words: map[string]string{LocaleEn: "reddishreddishreddish"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := PaletteColor{Key: "red", Name: "紅", Hex: "#FF0000", Words: tt.words}.validateWords()
+			if !tt.wantErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr && !errors.Is(err, NewError(ErrCodeInvalidPalette)) {
+				t.Fatalf("err = %v, want %s", err, ErrCodeInvalidPalette)
+			}
+		})
+	}
+}
+
+func TestQuizLanguages(t *testing.T) {
+	tests := []struct {
+		name      string
+		room      string
+		secondary string
+		player    string
+		want      []string
+	}{
+		{name: "room language", room: LocaleZhTW, want: []string{LocaleZhTW}},
+		{name: "player overrides room", room: LocaleZhTW, player: LocaleEn, want: []string{LocaleEn}},
+		{name: "bilingual", room: LocaleEn, secondary: LocaleZhTW, want: []string{LocaleEn, LocaleZhTW}},
+		{name: "secondary same as primary", room: LocaleEn, secondary: LocaleZhTW, player: LocaleZhTW, want: []string{LocaleZhTW}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			room := NewRoom("locale")
+			room.Settings.QuizLanguage = tt.room
+			room.Settings.SecondaryLanguage = tt.secondary
+			player := NewPlayer(NewMemoryConn("alice"), "alice", false)
+			if err := player.SetLanguage(tt.player); err != nil {
+				t.Fatalf("set language: %v", err)
+			}
+			if got := room.quizLanguages(player); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("languages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBilingualQuizAlternates(t *testing.T) {
+	g := NewGameWithSeed(DefaultRoomSettings(), 1)
+	g.SetLanguages([]string{LocaleEn, LocaleZhTW})
+	for i, want := range []string{LocaleEn, LocaleZhTW, LocaleEn} {
+		g.Progress = i
+		status, err := g.GetStatus()
+		if err != nil {
+			t.Fatalf("status: %v", err)
+		}
+		if status.QuizLanguage != want || status.Quiz != g.palette.Word(g.QuizList[i], want) {
+			t.Fatalf("quiz %d = %q (%s), want %s word", i, status.Quiz, status.QuizLanguage, want)
+		}
+	}
+}
+
+func TestLanguageSettingsValidation(t *testing.T) {
+	settings := DefaultRoomSettings()
+	settings.QuizLanguage = "fr"
+	if err := settings.Validate(); !errors.Is(err, NewError(ErrCodeInvalidSettings)) {
+		t.Fatalf("unsupported quiz language: err = %v", err)
+	}
+	settings = DefaultRoomSettings()
+	settings.SecondaryLanguage = LocaleZhTW
+	if err := settings.Validate(); err != nil {
+		t.Fatalf("bilingual settings: %v", err)
+	}
+	if err := NewPlayer(NewMemoryConn("alice"), "alice", false).SetLanguage("fr"); !errors.Is(err, NewError(ErrCodeInvalidSettings)) {
+		t.Fatalf("unsupported player language: err = %v", err)
+	}
+}
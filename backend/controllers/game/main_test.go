@@ -0,0 +1,85 @@
+package game
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rejxcy/logger"
+)
+
+func TestMain(m *testing.M) {
+	// 房間邏輯會寫入記錄，測試前先初始化，記錄寫入暫存目錄避免干擾測試輸出
+	dir, err := os.MkdirTemp("", "game-test")
+	if err != nil {
+		panic(err)
+	}
+	if err := logger.InitLogger(logger.FileOutput, filepath.Join(dir, "test.log")); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// 測試用的客戶端收到的消息
+type receivedMessage struct {
+	ID        string          `json:"id"`
+	Seq       uint64          `json:"seq"`
+	PlayerSeq uint64          `json:"pseq"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// 取出程序內連線目前所有的伺服器消息；伺服器在處理消息時同步寫入，處理完成後即可讀取
+func drainMessages(t *testing.T, conn *MemoryConn) []receivedMessage {
+	t.Helper()
+	conn.mu.Lock()
+	outbox := conn.outbox
+	conn.outbox = nil
+	conn.mu.Unlock()
+
+	msgs := make([]receivedMessage, 0, len(outbox))
+	for _, data := range outbox {
+		var msg receivedMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			t.Fatalf("decode server message %s: %v", data, err)
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+// 各類型消息的數量
+func messageTypes(msgs []receivedMessage) map[string]int {
+	types := make(map[string]int)
+	for _, msg := range msgs {
+		types[msg.Type]++
+	}
+	return types
+}
+
+// 以客戶端身分送出一則 JSON 消息，經過與實際連線相同的解碼與處理流程
+func deliver(t *testing.T, room *Room, player *Player, msg string) {
+	t.Helper()
+	(&controller{}).dispatchMessage(room, player, []byte(msg))
+}
+
+// 以程序內連線建立玩家並加入房間
+func joinTestPlayer(t *testing.T, room *Room, name string) (*Player, *MemoryConn) {
+	t.Helper()
+	conn := NewMemoryConn(name)
+	player := NewPlayer(conn, name, false)
+	if err := room.AddPlayer(player); err != nil {
+		t.Fatalf("add player %s: %v", name, err)
+	}
+	return player, conn
+}
+
+// 目前題目的正確答案
+func currentAnswer(room *Room, player *Player) string {
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	return player.Game.QuizList[player.Game.Progress]
+}
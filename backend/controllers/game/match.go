@@ -0,0 +1,225 @@
+package game
+
+import (
+	"sort"
+
+	"github.com/rejxcy/logger"
+)
+
+// NewMatch 建立指定回合數的比賽
+func NewMatch(rounds int) *Match {
+	return &Match{
+		TotalRounds:  rounds,
+		CurrentRound: 0,
+		RoundScores:  make([]map[string]int, 0, rounds),
+		TotalScores:  make(map[string]int),
+		PlayerNames:  make(map[string]string),
+	}
+}
+
+// 預設的房間設定
+func DefaultRoomSettings() RoomSettings {
+	return RoomSettings{
+		Mode:          GameModeClassic,
+		QuizCount:     QuizCount,
+		TimeLimit:     DefaultTimeLimit,
+		Rounds:        DefaultRounds,
+		Scoring:       DefaultScoringRules(),
+		PalettePreset: PalettePresetDefault,
+		Palette:       DefaultPalette(),
+		QuizLanguage:  DefaultQuizLanguage,
+		ReadyCheck:    DefaultReadyCheckRules(),
+		LateJoin:      LateJoinSpectate,
+	}
+}
+
+// 驗證房間設定是否合法
+func (s RoomSettings) Validate() error {
+	if s.Rounds < 1 || s.Rounds > MaxRounds {
+		return NewError(ErrCodeInvalidSettings)
+	}
+	switch s.Mode {
+	case GameModeClassic:
+		if s.QuizCount < 1 || s.QuizCount > MaxQuizCount {
+			return NewError(ErrCodeInvalidSettings)
+		}
+	case GameModeTimed:
+		if s.TimeLimit < MinTimeLimit || s.TimeLimit > MaxTimeLimit {
+			return NewError(ErrCodeInvalidSettings)
+		}
+	default:
+		return NewError(ErrCodeInvalidSettings)
+	}
+	if err := s.Scoring.Validate(); err != nil {
+		return err
+	}
+	if err := s.validateLanguages(); err != nil {
+		return err
+	}
+	if err := s.ReadyCheck.Validate(); err != nil {
+		return err
+	}
+	if err := s.validateLateJoin(); err != nil {
+		return err
+	}
+	return s.Palette.Validate()
+}
+
+// 是否為比賽的最後一回合
+func (m *Match) IsLastRound() bool {
+	return m.CurrentRound >= m.TotalRounds
+}
+
+// 開始下一回合
+func (m *Match) nextRound() {
+	m.CurrentRound++
+}
+
+// 記錄本回合各玩家分數並累加至總分
+// 以玩家 ID 為鍵，斷線後以重新連線憑證恢復的玩家沿用原本的 ID，分數累加在同一列
+func (m *Match) recordRound(players []*Player) {
+	scores := make(map[string]int, len(players))
+	for _, p := range players {
+		scores[p.ID] = p.Score
+		m.TotalScores[p.ID] += p.Score
+		m.PlayerNames[p.ID] = p.Name
+	}
+	m.RoundScores = append(m.RoundScores, scores)
+}
+
+// 依累積總分計算排名
+func (m *Match) Standings() []MatchStanding {
+	standings := make([]MatchStanding, 0, len(m.TotalScores))
+	for id, total := range m.TotalScores {
+		roundScores := make([]int, len(m.RoundScores))
+		for i, round := range m.RoundScores {
+			roundScores[i] = round[id]
+		}
+		standings = append(standings, MatchStanding{
+			ID:          id,
+			Name:        m.PlayerNames[id],
+			RoundScores: roundScores,
+			TotalScore:  total,
+		})
+	}
+
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].TotalScore != standings[j].TotalScore {
+			return standings[i].TotalScore > standings[j].TotalScore
+		}
+		return standings[i].Name < standings[j].Name
+	})
+
+	// 同分者名次相同
+	for i := range standings {
+		if i > 0 && standings[i].TotalScore == standings[i-1].TotalScore {
+			standings[i].Rank = standings[i-1].Rank
+		} else {
+			standings[i].Rank = i + 1
+		}
+	}
+	return standings
+}
+
+// 取得總分最高的玩家（同分時可能有多位）
+func (m *Match) Winners() []MatchStanding {
+	winners := make([]MatchStanding, 0, 1)
+	for _, s := range m.Standings() {
+		if s.Rank != 1 {
+			break
+		}
+		winners = append(winners, s)
+	}
+	return winners
+}
+
+// 記錄本回合分數並切換房間狀態（回合間休息或比賽結束），呼叫時需持有 r.mu
+func (r *Room) closeRoundLocked() *Match {
+	players := make([]*Player, 0, len(r.Players))
+	for _, p := range r.Players {
+		if p.isRanked() {
+			players = append(players, p)
+		}
+	}
+	r.Match.recordRound(players)
+
+	if r.Match.IsLastRound() {
+		r.Status = RoomStatusFinished
+	} else {
+		r.Status = RoomStatusIntermission
+	}
+	return r.Match
+}
+
+// 廣播回合結果；最後一回合時一併廣播比賽結果
+func (r *Room) broadcastRoundResult(match *Match) {
+	standings := match.Standings()
+	r.Broadcast(Message{
+		Type: MsgTypeRoundEnd,
+		Payload: RoundEndPayload{
+			Round:       match.CurrentRound,
+			TotalRounds: match.TotalRounds,
+			Standings:   standings,
+		},
+	})
+
+	if match.IsLastRound() {
+		if r.OnMatchEnd != nil {
+			defer r.OnMatchEnd(standings)
+		}
+		winners := match.Winners()
+		logger.Output.Info("房間 %s 比賽結束，共 %d 回合", r.ID, match.TotalRounds)
+		r.Broadcast(Message{
+			Type: MsgTypeMatchEnd,
+			Payload: MatchEndPayload{
+				TotalRounds: match.TotalRounds,
+				Standings:   standings,
+				Winners:     winners,
+			},
+		})
+		return
+	}
+	logger.Output.Info("房間 %s 第 %d/%d 回合結束", r.ID, match.CurrentRound, match.TotalRounds)
+}
+
+// 更新房間設定（僅能在等待或比賽結束時調整），並廣播給所有玩家
+func (r *Room) UpdateSettings(settings RoomSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	if r.Status == RoomStatusPlaying || r.Status == RoomStatusIntermission {
+		r.mu.Unlock()
+		return NewError(ErrCodeGameInProgress)
+	}
+	r.Settings = settings
+	r.mu.Unlock()
+
+	r.BroadcastSettings()
+	r.checkReady()
+	return nil
+}
+
+// 發送目前房間設定給指定玩家（例如剛加入房間時）
+func (r *Room) SendSettings(player *Player) {
+	r.mu.Lock()
+	settings := r.Settings
+	r.mu.Unlock()
+
+	if err := player.Send(Message{Type: MsgTypeRoomSettings, Payload: settings}); err != nil {
+		logger.Output.Error("發送房間設定給 %s 失敗: %v", player.Name, err)
+	}
+}
+
+// 廣播目前房間設定
+func (r *Room) BroadcastSettings() {
+	r.mu.Lock()
+	settings := r.Settings
+	r.mu.Unlock()
+
+	r.Broadcast(Message{
+		Type:    MsgTypeRoomSettings,
+		Payload: settings,
+	})
+}
@@ -0,0 +1,87 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchStandings(t *testing.T) {
+	alice := &Player{ID: "a", Name: "alice"}
+	bob := &Player{ID: "b", Name: "bob"}
+	carol := &Player{ID: "c", Name: "carol"}
+
+	m := NewMatch(2)
+	alice.Score, bob.Score, carol.Score = 30, 10, 20
+	m.recordRound([]*Player{alice, bob, carol})
+	// 第二回合 carol 未參加，該回合分數記為 0
+	alice.Score, bob.Score = 0, 40
+	m.recordRound([]*Player{alice, bob})
+
+	standings := m.Standings()
+	want := []MatchStanding{
+		{ID: "b", Name: "bob", RoundScores: []int{10, 40}, TotalScore: 50, Rank: 1},
+		{ID: "a", Name: "alice", RoundScores: []int{30, 0}, TotalScore: 30, Rank: 2},
+		{ID: "c", Name: "carol", RoundScores: []int{20, 0}, TotalScore: 20, Rank: 3},
+	}
+	if !reflect.DeepEqual(standings, want) {
+		t.Fatalf("standings = %+v, want %+v", standings, want)
+	}
+}
+
+func TestMatchWinnersShareRank(t *testing.T) {
+	m := NewMatch(1)
+	m.recordRound([]*Player{
+		{ID: "a", Name: "alice", Score: 20},
+		{ID: "b", Name: "bob", Score: 20},
+		{ID: "c", Name: "carol", Score: 10},
+	})
+
+	winners := m.Winners()
+	if len(winners) != 2 || winners[0].Name != "alice" || winners[1].Name != "bob" {
+		t.Fatalf("winners = %+v, want alice and bob", winners)
+	}
+	if standings := m.Standings(); standings[2].Rank != 3 {
+		t.Fatalf("carol rank = %d, want 3", standings[2].Rank)
+	}
+}
+
+// 開始一回合並以指定分數結束
+func playRound(t *testing.T, room *Room, scores map[*Player]int) {
+	t.Helper()
+	if err := room.ForceStart(); err != nil {
+		t.Fatalf("start round: %v", err)
+	}
+	room.mu.Lock()
+	for p, score := range scores {
+		p.Score = score
+	}
+	room.mu.Unlock()
+	room.endGame()
+}
+
+func TestMatchTotalsSurviveResume(t *testing.T) {
+	room := NewRoom("match")
+	room.Settings.Rounds = 2
+	alice, _ := joinTestPlayer(t, room, "alice")
+	bob, _ := joinTestPlayer(t, room, "bob")
+	playRound(t, room, map[*Player]int{alice: 30, bob: 10})
+
+	// 回合間斷線後以重新連線憑證回到房間，第二回合的分數累加在原本的那一列
+	room.RemovePlayer(alice.ID)
+	rejoined := NewPlayer(NewMemoryConn("alice"), "alice", false)
+	room.Resume(rejoined, alice.session)
+	if err := room.AddPlayer(rejoined); err != nil {
+		t.Fatalf("rejoin: %v", err)
+	}
+	playRound(t, room, map[*Player]int{rejoined: 5, bob: 10})
+
+	room.mu.Lock()
+	standings := room.Match.Standings()
+	room.mu.Unlock()
+	if len(standings) != 2 {
+		t.Fatalf("got %d standings, want 2: %+v", len(standings), standings)
+	}
+	if s := standings[0]; s.ID != alice.ID || s.TotalScore != 35 || !reflect.DeepEqual(s.RoundScores, []int{30, 5}) {
+		t.Fatalf("alice standing = %+v, want both rounds under the original ID", s)
+	}
+}
@@ -0,0 +1,348 @@
+package game
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rejxcy/logger"
+)
+
+// 快速配對相關常數
+const (
+	DefaultQueueRoomSize     = 4
+	DefaultQueueMinPlayers   = 2
+	DefaultQueueTimeout      = 15 * time.Second
+	DefaultQueueRatingBucket = 300
+	matchmakingTick          = time.Second
+	quickPlayCountdown       = 3
+	quickPlayRoomPrefix      = "Q-"
+)
+
+// 配對規則：等待人數達到 RoomSize 時立即開房；最早加入的玩家等待超過 Timeout 時，只要達到 MinPlayers 即開房
+type MatchmakingConfig struct {
+	RoomSize   int
+	MinPlayers int
+	Timeout    time.Duration
+	// 積分分組的區間寬度，優先配對積分落在同一區間的玩家；0 表示不依積分分組
+	// 等待超過 Timeout 仍湊不到 MinPlayers 時，每多等一個 Timeout 便向兩側多納入一個相鄰區間
+	RatingBucket int
+}
+
+// 配對佇列中的一位玩家
+type QueueTicket struct {
+	ID       string
+	Player   *Player
+	Mode     GameMode
+	Rating   *int
+	JoinedAt time.Time
+	key      queueKey
+	// 配對成功後分配到的房間
+	room *Room
+}
+
+// 排隊狀態，發送給佇列中的玩家
+type QueueStatus struct {
+	Mode     GameMode `json:"mode"`
+	Position int      `json:"position"`
+	Waiting  int      `json:"waiting"`
+	RoomSize int      `json:"roomSize"`
+}
+
+// 配對成功通知
+type MatchFound struct {
+	RoomID  string   `json:"roomId"`
+	Mode    GameMode `json:"mode"`
+	Players []string `json:"players"`
+}
+
+// 同一佇列中的玩家模式相同且積分落在同一區間（未提供積分的玩家為獨立的一組）
+type queueKey struct {
+	mode   GameMode
+	bucket int
+}
+
+// Matchmaker 依模式與積分將排隊的玩家分組，建立由伺服器主持的房間並自動開始
+type Matchmaker struct {
+	config    MatchmakingConfig
+	queues    map[queueKey][]*QueueTicket
+	storeRoom func(*Room)
+	mu        sync.Mutex
+}
+
+// DefaultMatchmakingConfig 返回預設的配對規則
+func DefaultMatchmakingConfig() MatchmakingConfig {
+	return MatchmakingConfig{
+		RoomSize:     DefaultQueueRoomSize,
+		MinPlayers:   DefaultQueueMinPlayers,
+		Timeout:      DefaultQueueTimeout,
+		RatingBucket: DefaultQueueRatingBucket,
+	}
+}
+
+// NewMatchmaker 創建配對器並啟動定期檢查等待逾時的背景工作
+func NewMatchmaker(config MatchmakingConfig, storeRoom func(*Room)) *Matchmaker {
+	if config.RoomSize < 1 || config.RoomSize > MaxPlayers {
+		config.RoomSize = DefaultQueueRoomSize
+	}
+	if config.MinPlayers < MinPlayers || config.MinPlayers > config.RoomSize {
+		config.MinPlayers = config.RoomSize
+	}
+	m := &Matchmaker{
+		config:    config,
+		queues:    make(map[queueKey][]*QueueTicket),
+		storeRoom: storeRoom,
+	}
+	go m.run()
+	return m
+}
+
+// 定期檢查各佇列，讓等待逾時的玩家以較少人數開房
+func (m *Matchmaker) run() {
+	ticker := time.NewTicker(matchmakingTick)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		m.match(now)
+	}
+}
+
+// Enqueue 將玩家加入指定模式的佇列，rating 為 nil 表示不依積分配對
+func (m *Matchmaker) Enqueue(player *Player, mode GameMode, rating *int) (*QueueTicket, error) {
+	settings := DefaultRoomSettings()
+	settings.Mode = mode
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+	if rating != nil && *rating < 0 {
+		return nil, NewError(ErrCodeInvalidSettings)
+	}
+
+	ticket := &QueueTicket{
+		ID:       uuid.New().String(),
+		Player:   player,
+		Mode:     mode,
+		Rating:   rating,
+		JoinedAt: time.Now(),
+		key:      m.keyFor(mode, rating),
+	}
+
+	m.mu.Lock()
+	m.queues[ticket.key] = append(m.queues[ticket.key], ticket)
+	m.mu.Unlock()
+
+	logger.Output.Info("Player %s queued for %s", player.Name, mode)
+	m.match(ticket.JoinedAt)
+	if m.Room(ticket) == nil {
+		m.notifyQueue(ticket.key)
+	}
+	return ticket, nil
+}
+
+// Leave 將玩家移出佇列；若玩家已被分配到房間則返回該房間
+func (m *Matchmaker) Leave(ticket *QueueTicket) *Room {
+	m.mu.Lock()
+	if ticket.room != nil {
+		m.mu.Unlock()
+		return ticket.room
+	}
+	m.removeLocked(ticket)
+	m.mu.Unlock()
+
+	logger.Output.Info("Player %s left the %s queue", ticket.Player.Name, ticket.Mode)
+	m.notifyQueue(ticket.key)
+	return nil
+}
+
+// 將玩家移出所在的佇列，呼叫時需持有 m.mu
+func (m *Matchmaker) removeLocked(ticket *QueueTicket) {
+	queue := m.queues[ticket.key]
+	for i, t := range queue {
+		if t == ticket {
+			m.queues[ticket.key] = append(queue[:i:i], queue[i+1:]...)
+			break
+		}
+	}
+	if len(m.queues[ticket.key]) == 0 {
+		delete(m.queues, ticket.key)
+	}
+}
+
+// Room 返回玩家被分配到的房間，仍在排隊時返回 nil
+func (m *Matchmaker) Room(ticket *QueueTicket) *Room {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return ticket.room
+}
+
+// 依模式與積分區間決定佇列
+func (m *Matchmaker) keyFor(mode GameMode, rating *int) queueKey {
+	key := queueKey{mode: mode, bucket: -1}
+	if rating != nil && m.config.RatingBucket > 0 {
+		key.bucket = *rating / m.config.RatingBucket
+	}
+	return key
+}
+
+// 檢查所有佇列，將符合條件的玩家分組開房，並通知仍在排隊的玩家最新狀態
+func (m *Matchmaker) match(now time.Time) {
+	m.mu.Lock()
+	var groups [][]*QueueTicket
+	changed := make([]queueKey, 0)
+	for key, queue := range m.queues {
+		before := len(queue)
+		for len(queue) >= m.config.RoomSize {
+			groups = append(groups, queue[:m.config.RoomSize:m.config.RoomSize])
+			queue = queue[m.config.RoomSize:]
+		}
+		if len(queue) >= m.config.MinPlayers && now.Sub(queue[0].JoinedAt) >= m.config.Timeout {
+			groups = append(groups, queue)
+			queue = nil
+		}
+		if len(queue) == 0 {
+			delete(m.queues, key)
+		} else {
+			m.queues[key] = queue
+		}
+		if len(queue) != before {
+			changed = append(changed, key)
+		}
+	}
+
+	// 等待逾時仍湊不到人的積分區間，依等待時間納入相鄰區間的玩家
+	keys := make([]queueKey, 0, len(m.queues))
+	for key := range m.queues {
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		queue := m.queues[key]
+		if key.bucket < 0 || len(queue) == 0 || now.Sub(queue[0].JoinedAt) < m.config.Timeout {
+			continue
+		}
+		span := int(now.Sub(queue[0].JoinedAt) / m.config.Timeout)
+		if group := m.widenLocked(key, span); group != nil {
+			groups = append(groups, group)
+			for _, ticket := range group {
+				changed = append(changed, ticket.key)
+			}
+		}
+	}
+
+	rooms := make([]*Room, 0, len(groups))
+	for _, group := range groups {
+		if room := m.createRoomLocked(group); room != nil {
+			rooms = append(rooms, room)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, room := range rooms {
+		m.startRoom(room)
+	}
+	for _, key := range changed {
+		m.notifyQueue(key)
+	}
+}
+
+// 合併前後 span 個積分區間的玩家，依等待時間先後取出最多 RoomSize 人並移出佇列；人數未達 MinPlayers 時返回 nil，呼叫時需持有 m.mu
+func (m *Matchmaker) widenLocked(key queueKey, span int) []*QueueTicket {
+	candidates := make([]*QueueTicket, 0, m.config.RoomSize)
+	for bucket := key.bucket - span; bucket <= key.bucket+span; bucket++ {
+		if bucket >= 0 {
+			candidates = append(candidates, m.queues[queueKey{mode: key.mode, bucket: bucket}]...)
+		}
+	}
+	if len(candidates) < m.config.MinPlayers {
+		return nil
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].JoinedAt.Before(candidates[j].JoinedAt)
+	})
+	if len(candidates) > m.config.RoomSize {
+		candidates = candidates[:m.config.RoomSize]
+	}
+	for _, ticket := range candidates {
+		m.removeLocked(ticket)
+	}
+	return candidates
+}
+
+// 為一組玩家建立由伺服器主持的房間，呼叫時需持有 m.mu
+func (m *Matchmaker) createRoomLocked(group []*QueueTicket) *Room {
+	code, err := newShortCode(ChallengeCodeLength)
+	if err != nil {
+		logger.Output.Error("產生快速配對房間代碼失敗: %v", err)
+		return nil
+	}
+	room := NewRoom(quickPlayRoomPrefix + code)
+	room.Settings.Mode = group[0].Mode
+	room.ServerHosted = true
+	// 由伺服器自動主持：配對完成後短暫倒數即開始，結束後持續循環直到玩家離開
+	room.AutoHost = &AutoHostConfig{
+		MinPlayers:          m.config.MinPlayers,
+		ReadyPercent:        0,
+		CountdownSeconds:    quickPlayCountdown,
+		IntermissionSeconds: DefaultAutoHostIntermission,
+	}
+
+	for _, ticket := range group {
+		ticket.Player.IsReady = true
+		if err := room.AddPlayer(ticket.Player); err != nil {
+			logger.Output.Error("將 %s 加入快速配對房間 %s 失敗: %v", ticket.Player.Name, room.ID, err)
+			continue
+		}
+		ticket.room = room
+	}
+	m.storeRoom(room)
+	logger.Output.Info("Quick-play room %s created for %d players (%s)", room.ID, len(group), room.Settings.Mode)
+	return room
+}
+
+// 通知玩家配對成功，由自動主持倒數開始遊戲
+func (m *Matchmaker) startRoom(room *Room) {
+	players := room.playerSnapshot()
+	names := make([]string, 0, len(players))
+	for _, p := range players {
+		names = append(names, p.Name)
+	}
+
+	found := Message{
+		Type: MsgTypeMatchFound,
+		Payload: MatchFound{
+			RoomID:  room.ID,
+			Mode:    room.Settings.Mode,
+			Players: names,
+		},
+	}
+	for _, p := range players {
+		if err := p.Send(found); err != nil {
+			logger.Output.Error("發送配對結果給 %s 失敗: %v", p.Name, err)
+		}
+		room.SendSettings(p)
+	}
+	room.BroadcastPlayerList()
+	room.checkAutoHost()
+}
+
+// 發送排隊狀態給指定佇列中的所有玩家
+func (m *Matchmaker) notifyQueue(key queueKey) {
+	m.mu.Lock()
+	queue := append([]*QueueTicket(nil), m.queues[key]...)
+	m.mu.Unlock()
+
+	for i, ticket := range queue {
+		msg := Message{
+			Type: MsgTypeQueueStatus,
+			Payload: QueueStatus{
+				Mode:     ticket.Mode,
+				Position: i + 1,
+				Waiting:  len(queue),
+				RoomSize: m.config.RoomSize,
+			},
+		}
+		if err := ticket.Player.Send(msg); err != nil {
+			logger.Output.Error("發送排隊狀態給 %s 失敗: %v", ticket.Player.Name, err)
+		}
+	}
+}
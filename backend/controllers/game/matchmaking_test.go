@@ -0,0 +1,138 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+// 建立不啟動背景檢查的配對器，由測試自行指定檢查時間
+func newTestMatchmaker(t *testing.T) (*Matchmaker, *[]*Room) {
+	t.Helper()
+	rooms := make([]*Room, 0)
+	m := &Matchmaker{
+		config: MatchmakingConfig{RoomSize: 4, MinPlayers: 2, Timeout: 10 * time.Second, RatingBucket: 300},
+		queues: make(map[queueKey][]*QueueTicket),
+		storeRoom: func(room *Room) {
+			rooms = append(rooms, room)
+		},
+	}
+	t.Cleanup(func() {
+		for _, room := range rooms {
+			room.Close()
+		}
+	})
+	return m, &rooms
+}
+
+// 直接將玩家放入佇列，rating 小於 0 表示不依積分配對
+func queueTestPlayer(m *Matchmaker, name string, mode GameMode, rating int, joinedAt time.Time) *QueueTicket {
+	var r *int
+	if rating >= 0 {
+		r = &rating
+	}
+	ticket := &QueueTicket{
+		Player:   NewPlayer(NewMemoryConn(name), name, false),
+		Mode:     mode,
+		Rating:   r,
+		JoinedAt: joinedAt,
+		key:      m.keyFor(mode, r),
+	}
+	m.queues[ticket.key] = append(m.queues[ticket.key], ticket)
+	return ticket
+}
+
+func TestMatchmakingFillsRooms(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		players int
+		waited  time.Duration
+		// 預期建立的房間人數
+		want []int
+	}{
+		{name: "full room starts immediately", players: 5, want: []int{4}},
+		{name: "not enough before timeout", players: 3},
+		{name: "min players after timeout", players: 3, waited: 10 * time.Second, want: []int{3}},
+		{name: "single player keeps waiting", players: 1, waited: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, rooms := newTestMatchmaker(t)
+			for i := 0; i < tt.players; i++ {
+				queueTestPlayer(m, "p"+string(rune('a'+i)), GameModeClassic, -1, now.Add(-tt.waited))
+			}
+			m.match(now)
+			if len(*rooms) != len(tt.want) {
+				t.Fatalf("created %d rooms, want %d", len(*rooms), len(tt.want))
+			}
+			for i, room := range *rooms {
+				if players := len(room.playerSnapshot()); players != tt.want[i] || !room.ServerHosted {
+					t.Fatalf("room %d has %d players (server hosted %v), want %d", i, players, room.ServerHosted, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMatchmakingWidensRatingBuckets(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		waited time.Duration
+		// 第二位玩家的積分與模式
+		rating int
+		mode   GameMode
+		match  bool
+	}{
+		{name: "same bucket waits for timeout", waited: 5 * time.Second, rating: 200, mode: GameModeClassic},
+		{name: "neighbour bucket before timeout", waited: 5 * time.Second, rating: 400, mode: GameModeClassic},
+		{name: "neighbour bucket after timeout", waited: 10 * time.Second, rating: 400, mode: GameModeClassic, match: true},
+		{name: "two buckets away needs a second timeout", waited: 10 * time.Second, rating: 700, mode: GameModeClassic},
+		{name: "two buckets away after two timeouts", waited: 20 * time.Second, rating: 700, mode: GameModeClassic, match: true},
+		{name: "different mode never matches", waited: time.Minute, rating: 100, mode: GameModeTimed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, rooms := newTestMatchmaker(t)
+			first := queueTestPlayer(m, "alice", GameModeClassic, 100, now.Add(-tt.waited))
+			second := queueTestPlayer(m, "bob", tt.mode, tt.rating, now)
+			m.match(now)
+
+			matched := len(*rooms) == 1 && first.room != nil && first.room == second.room
+			if matched != tt.match {
+				t.Fatalf("matched = %v (%d rooms), want %v", matched, len(*rooms), tt.match)
+			}
+			queued := 0
+			for _, queue := range m.queues {
+				queued += len(queue)
+			}
+			if !tt.match && queued != 2 {
+				t.Fatalf("%d players still queued, want 2", queued)
+			}
+		})
+	}
+}
+
+func TestMatchmakingLeave(t *testing.T) {
+	m, rooms := newTestMatchmaker(t)
+	now := time.Now()
+	waiting := queueTestPlayer(m, "alice", GameModeClassic, -1, now)
+	if room := m.Leave(waiting); room != nil {
+		t.Fatalf("leave while waiting returned room %s", room.ID)
+	}
+	if len(m.queues) != 0 {
+		t.Fatalf("queues = %v, want empty", m.queues)
+	}
+
+	// 已配對成功的玩家離開佇列時取得分配到的房間
+	var tickets []*QueueTicket
+	for _, name := range []string{"a", "b", "c", "d"} {
+		tickets = append(tickets, queueTestPlayer(m, name, GameModeClassic, -1, now))
+	}
+	m.match(now)
+	if room := m.Leave(tickets[0]); room == nil || room != (*rooms)[0] {
+		t.Fatalf("leave after match = %v, want the created room", room)
+	}
+}
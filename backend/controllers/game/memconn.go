@@ -0,0 +1,136 @@
+package game
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// 程序內連線尚未被讀取循環處理的客戶端消息上限
+const memoryInboxSize = 32
+
+// MemoryConn 程序內的連線，不經過網路，供測試與伺服器端的機器人玩家使用
+// 伺服器端經由 Conn 介面讀寫；客戶端以 Deliver 發送消息、以 Receive 依序取得伺服器消息
+type MemoryConn struct {
+	name  string
+	inbox chan []byte
+	done  chan struct{}
+	// 伺服器消息佇列，寫入不阻塞，避免在持有房間鎖時等待客戶端
+	mu      sync.Mutex
+	outbox  [][]byte
+	pending chan struct{}
+	closed  bool
+	// 以 CloseWithCode 關閉時的關閉碼與原因
+	closeCode   int
+	closeReason string
+}
+
+// NewMemoryConn 建立程序內連線，name 作為 RemoteAddr 供記錄辨識
+func NewMemoryConn(name string) *MemoryConn {
+	return &MemoryConn{
+		name:    name,
+		inbox:   make(chan []byte, memoryInboxSize),
+		done:    make(chan struct{}),
+		pending: make(chan struct{}, 1),
+	}
+}
+
+// 程序內連線固定使用 JSON 編碼
+func (c *MemoryConn) Codec() Codec { return jsonCodec{} }
+
+func (c *MemoryConn) ReadMessage() ([]byte, error) {
+	select {
+	case data := <-c.inbox:
+		return data, nil
+	case <-c.done:
+		return nil, NewError(ErrCodeConnectionClosed)
+	}
+}
+
+func (c *MemoryConn) WriteMessage(data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return NewError(ErrCodeConnectionClosed)
+	}
+	c.outbox = append(c.outbox, data)
+	select {
+	case c.pending <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (c *MemoryConn) CloseWithCode(code int, reason string) {
+	c.mu.Lock()
+	if !c.closed {
+		c.closeCode = code
+		c.closeReason = reason
+	}
+	c.mu.Unlock()
+	c.Close()
+}
+
+func (c *MemoryConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.closed = true
+		close(c.done)
+	}
+	return nil
+}
+
+func (c *MemoryConn) RemoteAddr() string { return "memory:" + c.name }
+
+// Deliver 以客戶端身分發送消息，msg 可為已編碼的 JSON 或任何可編碼為 JSON 的值（例如 Envelope）
+func (c *MemoryConn) Deliver(msg interface{}) error {
+	data, ok := msg.([]byte)
+	if !ok {
+		var err error
+		if data, err = json.Marshal(msg); err != nil {
+			return err
+		}
+	}
+	// 收件匣仍有空間時 select 可能選到寫入，先確認連線未關閉
+	select {
+	case <-c.done:
+		return NewError(ErrCodeConnectionClosed)
+	default:
+	}
+	select {
+	case c.inbox <- data:
+		return nil
+	case <-c.done:
+		return NewError(ErrCodeConnectionClosed)
+	}
+}
+
+// Receive 以客戶端身分依序取得伺服器消息，沒有消息時阻塞；連線關閉且佇列已清空後返回錯誤
+func (c *MemoryConn) Receive() ([]byte, error) {
+	for {
+		c.mu.Lock()
+		if len(c.outbox) > 0 {
+			data := c.outbox[0]
+			c.outbox = c.outbox[1:]
+			c.mu.Unlock()
+			return data, nil
+		}
+		closed := c.closed
+		c.mu.Unlock()
+		if closed {
+			return nil, NewError(ErrCodeConnectionClosed)
+		}
+
+		select {
+		case <-c.pending:
+		case <-c.done:
+		}
+	}
+}
+
+// CloseCode 以 CloseWithCode 關閉時的關閉碼與原因，未以關閉碼關閉時返回 0
+func (c *MemoryConn) CloseCode() (int, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closeCode, c.closeReason
+}
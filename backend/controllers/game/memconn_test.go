@@ -0,0 +1,137 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestMemoryConnRoundTrip(t *testing.T) {
+	conn := NewMemoryConn("alice")
+	if conn.RemoteAddr() != "memory:alice" {
+		t.Fatalf("remote addr = %q, want memory:alice", conn.RemoteAddr())
+	}
+
+	// 客戶端消息可以是已編碼的 JSON 或可編碼的值
+	if err := conn.Deliver(Envelope{Type: MsgTypeReady, Payload: json.RawMessage(`true`)}); err != nil {
+		t.Fatalf("deliver envelope: %v", err)
+	}
+	if err := conn.Deliver([]byte(`{"type":"answer","payload":"red"}`)); err != nil {
+		t.Fatalf("deliver bytes: %v", err)
+	}
+	for _, want := range []string{MsgTypeReady, MsgTypeAnswer} {
+		data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("read message: %v", err)
+		}
+		env, err := conn.Codec().Decode(data)
+		if err != nil || env.Type != want {
+			t.Fatalf("read %s (err %v), want %s", data, err, want)
+		}
+	}
+
+	for _, msg := range []string{`{"type":"welcome"}`, `{"type":"player_list"}`} {
+		if err := conn.WriteMessage([]byte(msg)); err != nil {
+			t.Fatalf("write message: %v", err)
+		}
+	}
+	conn.CloseWithCode(websocket.ClosePolicyViolation, "rate limit exceeded")
+	if code, reason := conn.CloseCode(); code != websocket.ClosePolicyViolation || reason != "rate limit exceeded" {
+		t.Fatalf("close code = %d %q, want policy violation", code, reason)
+	}
+
+	// 關閉後仍可依序讀完已寫入的伺服器消息，之後的讀寫都返回連線已關閉
+	for _, want := range []string{`{"type":"welcome"}`, `{"type":"player_list"}`} {
+		data, err := conn.Receive()
+		if err != nil || string(data) != want {
+			t.Fatalf("receive = %s (err %v), want %s", data, err, want)
+		}
+	}
+	closed := NewError(ErrCodeConnectionClosed)
+	if _, err := conn.Receive(); !errors.Is(err, closed) {
+		t.Fatalf("receive after close: err = %v, want %s", err, ErrCodeConnectionClosed)
+	}
+	if _, err := conn.ReadMessage(); !errors.Is(err, closed) {
+		t.Fatalf("read after close: err = %v, want %s", err, ErrCodeConnectionClosed)
+	}
+	if err := conn.WriteMessage([]byte(`{}`)); !errors.Is(err, closed) {
+		t.Fatalf("write after close: err = %v, want %s", err, ErrCodeConnectionClosed)
+	}
+	if err := conn.Deliver([]byte(`{}`)); !errors.Is(err, closed) {
+		t.Fatalf("deliver after close: err = %v, want %s", err, ErrCodeConnectionClosed)
+	}
+}
+
+func TestMemoryConnReceiveWaitsForWrite(t *testing.T) {
+	conn := NewMemoryConn("alice")
+	received := make(chan []byte, 1)
+	go func() {
+		data, _ := conn.Receive()
+		received <- data
+	}()
+
+	if err := conn.WriteMessage([]byte(`{"type":"welcome"}`)); err != nil {
+		t.Fatalf("write message: %v", err)
+	}
+	select {
+	case data := <-received:
+		if string(data) != `{"type":"welcome"}` {
+			t.Fatalf("receive = %s, want the written message", data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("receive did not wake up after a write")
+	}
+}
+
+func TestMemoryConnDrivesPlayerLoop(t *testing.T) {
+	room := NewRoom("memory")
+	joinTestPlayer(t, room, "alice")
+	bob, conn := joinTestPlayer(t, room, "bob")
+
+	// 程序內連線走與網路連線相同的消息循環
+	done := make(chan struct{})
+	go func() {
+		(&controller{}).handlePlayerMessages(room, bob)
+		close(done)
+	}()
+
+	if err := conn.Deliver([]byte(`{"id":"r1","type":"ready","payload":true}`)); err != nil {
+		t.Fatalf("deliver ready: %v", err)
+	}
+	for {
+		data, err := conn.Receive()
+		if err != nil {
+			t.Fatalf("connection closed before the ready ack: %v", err)
+		}
+		var msg receivedMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			t.Fatalf("decode server message %s: %v", data, err)
+		}
+		if msg.Type == MsgTypeAck && msg.ID == "r1" {
+			break
+		}
+	}
+	room.mu.Lock()
+	ready := bob.IsReady
+	room.mu.Unlock()
+	if !ready {
+		t.Fatal("bob is not ready after the acknowledged ready message")
+	}
+
+	// 連線關閉後消息循環結束並將玩家移出房間
+	conn.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("player loop did not exit after the connection closed")
+	}
+	room.mu.Lock()
+	_, inRoom := room.Players[bob.ID]
+	room.mu.Unlock()
+	if inRoom {
+		t.Fatal("bob is still in the room after the connection closed")
+	}
+}
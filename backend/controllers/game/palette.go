@@ -0,0 +1,170 @@
+package game
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// 調色盤預設組名稱
+const (
+	PalettePresetDefault        = "default"
+	PalettePresetDeuteranopia   = "deuteranopia"
+	PalettePresetHighContrast   = "high_contrast"
+	PalettePresetExtended       = "extended"
+	PalettePresetCustom         = "custom"
+	MinPaletteColors            = 2
+	MaxPaletteColors            = 12
+	maxPaletteColorNameLength   = 16
+	maxPaletteDisplayNameLength = 32
+)
+
+var (
+	paletteKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,23}$`)
+	paletteHexPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+)
+
+// 內建的調色盤預設組
+var palettePresets = map[string]Palette{
+	PalettePresetDefault: {
+		Name: "預設",
+		Colors: []PaletteColor{
+			{Key: "red", Name: "紅", Hex: "#E53935", Words: words("red", "紅")},
+			{Key: "green", Name: "綠", Hex: "#43A047", Words: words("green", "綠")},
+			{Key: "blue", Name: "藍", Hex: "#1E88E5", Words: words("blue", "藍")},
+			{Key: "yellow", Name: "黃", Hex: "#FDD835", Words: words("yellow", "黃")},
+			{Key: "orange", Name: "橙", Hex: "#FB8C00", Words: words("orange", "橙")},
+			{Key: "purple", Name: "紫", Hex: "#8E24AA", Words: words("purple", "紫")},
+		},
+	},
+	// 採用 Okabe-Ito 配色，紅綠色盲也能分辨
+	PalettePresetDeuteranopia: {
+		Name: "色盲友善",
+		Colors: []PaletteColor{
+			{Key: "orange", Name: "橙", Hex: "#E69F00", Words: words("orange", "橙")},
+			{Key: "sky", Name: "天藍", Hex: "#56B4E9", Words: words("sky blue", "天藍")},
+			{Key: "teal", Name: "藍綠", Hex: "#009E73", Words: words("teal", "藍綠")},
+			{Key: "yellow", Name: "黃", Hex: "#F0E442", Words: words("yellow", "黃")},
+			{Key: "blue", Name: "藍", Hex: "#0072B2", Words: words("blue", "藍")},
+			{Key: "vermillion", Name: "朱紅", Hex: "#D55E00", Words: words("vermillion", "朱紅")},
+		},
+	},
+	PalettePresetHighContrast: {
+		Name: "高對比",
+		Colors: []PaletteColor{
+			{Key: "black", Name: "黑", Hex: "#000000", Words: words("black", "黑")},
+			{Key: "white", Name: "白", Hex: "#FFFFFF", Words: words("white", "白")},
+			{Key: "yellow", Name: "黃", Hex: "#FFFF00", Words: words("yellow", "黃")},
+			{Key: "blue", Name: "藍", Hex: "#0000FF", Words: words("blue", "藍")},
+		},
+	},
+	PalettePresetExtended: {
+		Name: "九色擴充",
+		Colors: []PaletteColor{
+			{Key: "red", Name: "紅", Hex: "#E53935", Words: words("red", "紅")},
+			{Key: "green", Name: "綠", Hex: "#43A047", Words: words("green", "綠")},
+			{Key: "blue", Name: "藍", Hex: "#1E88E5", Words: words("blue", "藍")},
+			{Key: "yellow", Name: "黃", Hex: "#FDD835", Words: words("yellow", "黃")},
+			{Key: "orange", Name: "橙", Hex: "#FB8C00", Words: words("orange", "橙")},
+			{Key: "purple", Name: "紫", Hex: "#8E24AA", Words: words("purple", "紫")},
+			{Key: "pink", Name: "粉紅", Hex: "#EC407A", Words: words("pink", "粉紅")},
+			{Key: "brown", Name: "棕", Hex: "#6D4C41", Words: words("brown", "棕")},
+			{Key: "gray", Name: "灰", Hex: "#757575", Words: words("gray", "灰")},
+		},
+	},
+}
+
+// 建立預設組使用的英文與中文文字
+func words(en, zhTW string) map[string]string {
+	return map[string]string{LocaleEn: en, LocaleZhTW: zhTW}
+}
+
+// DefaultPalette 返回預設調色盤
+func DefaultPalette() Palette {
+	palette, _ := PalettePreset(PalettePresetDefault)
+	return palette
+}
+
+// PalettePreset 依名稱取得內建調色盤的副本
+func PalettePreset(name string) (Palette, bool) {
+	preset, ok := palettePresets[name]
+	if !ok {
+		return Palette{}, false
+	}
+	preset.Colors = append([]PaletteColor(nil), preset.Colors...)
+	return preset, true
+}
+
+// 驗證調色盤：顏色數量、鍵值格式、色碼格式及不可重複
+func (p Palette) Validate() error {
+	if utf8.RuneCountInString(p.Name) > maxPaletteDisplayNameLength {
+		return NewError(ErrCodeInvalidPalette)
+	}
+	if len(p.Colors) < MinPaletteColors || len(p.Colors) > MaxPaletteColors {
+		return NewError(ErrCodeInvalidPalette)
+	}
+
+	keys := make(map[string]bool, len(p.Colors))
+	hexes := make(map[string]bool, len(p.Colors))
+	for _, c := range p.Colors {
+		nameLength := utf8.RuneCountInString(c.Name)
+		if nameLength == 0 || nameLength > maxPaletteColorNameLength {
+			return NewError(ErrCodeInvalidPalette)
+		}
+		if !paletteKeyPattern.MatchString(c.Key) || !paletteHexPattern.MatchString(c.Hex) {
+			return NewError(ErrCodeInvalidPalette)
+		}
+		if err := c.validateWords(); err != nil {
+			return err
+		}
+		hex := strings.ToUpper(c.Hex)
+		if keys[c.Key] || hexes[hex] {
+			return NewError(ErrCodeInvalidPalette)
+		}
+		keys[c.Key] = true
+		hexes[hex] = true
+	}
+	return nil
+}
+
+// 依預設組名稱決定實際使用的調色盤，自訂調色盤則保留房主提供的內容
+func (s *RoomSettings) resolvePalette() error {
+	if s.PalettePreset == PalettePresetCustom {
+		return nil
+	}
+	preset, ok := PalettePreset(s.PalettePreset)
+	if !ok {
+		return NewError(ErrCodeInvalidPalette)
+	}
+	s.Palette = preset
+	return nil
+}
+
+// Keys 返回調色盤中所有可作答的顏色鍵值
+func (p Palette) Keys() []string {
+	keys := make([]string, len(p.Colors))
+	for i, c := range p.Colors {
+		keys[i] = c.Key
+	}
+	return keys
+}
+
+// Hex 依顏色鍵值取得色碼
+func (p Palette) Hex(key string) string {
+	for _, c := range p.Colors {
+		if c.Key == key {
+			return c.Hex
+		}
+	}
+	return ""
+}
+
+// Has 判斷顏色鍵值是否屬於此調色盤
+func (p Palette) Has(key string) bool {
+	for _, c := range p.Colors {
+		if c.Key == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package game
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPalettePresetsAreValid(t *testing.T) {
+	for name := range palettePresets {
+		palette, ok := PalettePreset(name)
+		if !ok {
+			t.Fatalf("preset %s not found", name)
+		}
+		if err := palette.Validate(); err != nil {
+			t.Errorf("preset %s: %v", name, err)
+		}
+	}
+}
+
+func TestPaletteValidate(t *testing.T) {
+	red := PaletteColor{Key: "red", Name: "紅", Hex: "#FF0000"}
+	blue := PaletteColor{Key: "blue", Name: "藍", Hex: "#0000FF"}
+	tests := []struct {
+		name    string
+		colors  []PaletteColor
+		wantErr bool
+	}{
+		{name: "valid", colors: []PaletteColor{red, blue}},
+		{name: "too few colors", colors: []PaletteColor{red}, wantErr: true},
+		{name: "duplicate key", colors: []PaletteColor{red, {Key: "red", Name: "藍", Hex: "#0000FF"}}, wantErr: true},
+		{name: "duplicate hex ignores case", colors: []PaletteColor{{Key: "a", Name: "a", Hex: "#abcdef"}, {Key: "b", Name: "b", Hex: "#ABCDEF"}}, wantErr: true},
+		{name: "invalid key", colors: []PaletteColor{red, {Key: "Blue", Name: "藍", Hex: "#0000FF"}}, wantErr: true},
+		{name: "invalid hex", colors: []PaletteColor{red, {Key: "blue", Name: "藍", Hex: "#00F"}}, wantErr: true},
+		{name: "empty name", colors: []PaletteColor{red, {Key: "blue", Hex: "#0000FF"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Palette{Name: "test", Colors: tt.colors}.Validate()
+			if !tt.wantErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr && !errors.Is(err, NewError(ErrCodeInvalidPalette)) {
+				t.Fatalf("err = %v, want %s", err, ErrCodeInvalidPalette)
+			}
+		})
+	}
+}
+
+func TestResolvePalette(t *testing.T) {
+	custom := Palette{Name: "mine", Colors: []PaletteColor{{Key: "a", Name: "a", Hex: "#000001"}, {Key: "b", Name: "b", Hex: "#000002"}}}
+	tests := []struct {
+		name    string
+		preset  string
+		want    string
+		wantErr bool
+	}{
+		{name: "preset replaces palette", preset: PalettePresetHighContrast, want: palettePresets[PalettePresetHighContrast].Name},
+		{name: "custom keeps palette", preset: PalettePresetCustom, want: "mine"},
+		{name: "unknown preset", preset: "neon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := RoomSettings{PalettePreset: tt.preset, Palette: custom}
+			err := settings.resolvePalette()
+			if tt.wantErr {
+				if !errors.Is(err, NewError(ErrCodeInvalidPalette)) {
+					t.Fatalf("err = %v, want %s", err, ErrCodeInvalidPalette)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if settings.Palette.Name != tt.want {
+				t.Fatalf("palette = %s, want %s", settings.Palette.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestGameUsesPaletteColors(t *testing.T) {
+	settings := DefaultRoomSettings()
+	settings.Palette, _ = PalettePreset(PalettePresetDeuteranopia)
+	g := NewGameWithSeed(settings, 1)
+	for i := range g.QuizList {
+		if !settings.Palette.Has(g.QuizList[i]) || !settings.Palette.Has(g.ColorList[i]) {
+			t.Fatalf("quiz %d uses %s/%s outside the palette", i, g.QuizList[i], g.ColorList[i])
+		}
+	}
+	if _, err := g.Answer("red"); !errors.Is(err, NewError(ErrCodeInvalidAnswer)) {
+		t.Fatalf("answer outside palette: err = %v, want %s", err, ErrCodeInvalidAnswer)
+	}
+}
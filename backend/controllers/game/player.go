@@ -1,15 +1,12 @@
 package game
 
 import (
-	"errors"
-
 	"github.com/google/uuid"
-	"github.com/gorilla/websocket"
 	"github.com/rejxcy/logger"
 )
 
 // NewPlayer 創建新玩家並初始化資料
-func NewPlayer(conn *websocket.Conn, name string, isHost bool) *Player {
+func NewPlayer(conn Conn, name string, isHost bool) *Player {
 	return &Player{
 		ID:      uuid.New().String(),
 		Name:    name,
@@ -17,108 +14,216 @@ func NewPlayer(conn *websocket.Conn, name string, isHost bool) *Player {
 		IsReady: false,
 		Score:   0,
 		Conn:    conn,
-		Game:    NewGame(),
+		Game:    NewGame(DefaultRoomSettings()),
+		limiter: newRateLimiter(),
 	}
 }
 
-// Send 透過 WriteJSON 發送消息給玩家前先檢查連線是否有效
+// Send 以連線協商的編碼發送消息給玩家，發送前先檢查連線是否有效
 func (p *Player) Send(msg Message) error {
+	return p.sendEncoded(msg, nil)
+}
+
+// 發送消息；廣播時傳入共用的編碼結果，同一則消息對相同編碼的連線只編碼一次
+func (p *Player) sendEncoded(msg Message, encoded map[string][]byte) error {
 	if p.Conn == nil {
 		logger.Output.Error("連線為 nil")
-		return errors.New("連線為 nil")
+		return NewError(ErrCodeConnectionClosed)
+	}
+	p.sendMu.Lock()
+	defer p.sendMu.Unlock()
+
+	codec := p.Conn.Codec()
+	data, cached := encoded[codec.Name()]
+	if !cached {
+		var err error
+		if data, err = codec.Encode(msg); err != nil {
+			return err
+		}
+		if encoded != nil {
+			encoded[codec.Name()] = data
+		}
 	}
-	return p.Conn.WriteJSON(msg)
+	return p.Conn.WriteMessage(data)
 }
 
-// SendError 發送錯誤消息給客戶端
-func (p *Player) SendError(code string, message string) {
-	p.Send(Message{
-		Type: MsgTypeError,
-		Payload: map[string]interface{}{
-			"code":    code,
-			"message": message,
-		},
-	})
+// SendError 以玩家的語系發送錯誤消息給客戶端
+func (p *Player) SendError(err error) {
+	p.Send(NewErrorMessage(err, p.errorLocale()))
+}
+
+// 錯誤訊息使用的語系：hello 宣告的語系，其次為玩家的題目語言，皆未設定時使用預設語系
+func (p *Player) errorLocale() string {
+	p.sendMu.Lock()
+	locale := p.locale
+	p.sendMu.Unlock()
+	if locale == "" {
+		locale = p.Language
+	}
+	if locale == "" {
+		locale = DefaultErrorLocale
+	}
+	return locale
 }
 
-// HandleMessage 根據消息類型分派處理（所有消息均由玩家處理）
-func (p *Player) HandleMessage(msg Message, room *Room) error {
-	switch msg.Type {
+// HandleMessage 根據消息類型分派處理（所有消息均由玩家處理），payload 為 decodeEnvelope 解碼後的負載
+func (p *Player) HandleMessage(msgType string, payload interface{}, room *Room) error {
+	switch msgType {
+	case MsgTypeHello:
+		return p.handleHello(*payload.(*HelloPayload), room)
+
 	case MsgTypeReady:
 		// 房主不需要設置準備狀態
 		if p.IsHost {
-			return errors.New("房主無需設置準備狀態")
-		}
-		ready, ok := msg.Payload.(bool)
-		if !ok {
-			return errors.New("無效的準備狀態")
+			return NewError(ErrCodeHostCannotReady)
 		}
+		ready := bool(*payload.(*ReadyPayload))
+		room.mu.Lock()
 		p.IsReady = ready
+		// 觀戰者在等待期間準備即重新加入比賽
+		if ready && room.Status != RoomStatusPlaying {
+			p.IsSpectator = false
+		}
+		room.mu.Unlock()
 		logger.Output.Info("Player %s ready state changed to: %v", p.Name, ready)
 		room.BroadcastPlayerList()
+		room.checkReady()
 		return nil
 
 	case MsgTypeGameStart:
 		return p.handleGameStart(room)
 
 	case MsgTypeAnswer:
-		return p.handleAnswer(msg.Payload, room)
+		return p.handleAnswer(string(*payload.(*AnswerPayload)), room)
 
 	case MsgTypeGameReset:
 		return p.handleGameReset(room)
 
+	case MsgTypeUpdateSettings:
+		return p.handleUpdateSettings(*payload.(*SettingsPatch), room)
+
+	case MsgTypeSetLanguage:
+		return p.handleSetLanguage(string(*payload.(*SetLanguagePayload)), room)
+
+	case MsgTypeSync:
+		room.Sync(p, *payload.(*SyncPayload))
+		return nil
+
+	case MsgTypeCreateChallenge:
+		return p.handleCreateChallenge(room)
+
+	case MsgTypeAddBots:
+		return p.handleAddBots(*payload.(*AddBotsPayload), room)
+
+	case MsgTypeRemoveBots:
+		if !p.IsHost {
+			return NewError(ErrCodeNotHost)
+		}
+		room.RemoveBots()
+		return nil
+
 	default:
-		return errors.New("未知的消息類型")
+		return NewError(ErrCodeUnknownMessage).With("type", msgType)
 	}
 }
 
-// handleGameStart 處理開始遊戲的請求（僅允許房主觸發）
+// handleGameStart 處理開始遊戲的請求（僅允許房主或單人練習的玩家觸發）
 func (p *Player) handleGameStart(room *Room) error {
-	if !p.IsHost {
-		return errors.New(ErrCodeNotHost)
+	if !p.IsHost && !room.Solo {
+		return NewError(ErrCodeNotHost)
 	}
 	return room.StartGame()
 }
 
 // handleAnswer 處理玩家提交的答案
-func (p *Player) handleAnswer(payload interface{}, room *Room) error {
+func (p *Player) handleAnswer(answer string, room *Room) error {
 	if !room.IsGameStarted() {
-		return errors.New(ErrCodeGameNotStarted)
-	}
-	answer, ok := payload.(string)
-	if !ok {
-		return errors.New(ErrCodeInvalidAnswer)
+		return NewError(ErrCodeGameNotStarted)
 	}
 	return room.HandleAnswer(p.ID, answer)
 }
 
-// handleGameReset 處理重置遊戲的請求（僅允許房主觸發）
+// handleGameReset 處理重置遊戲的請求（僅允許房主或單人練習的玩家觸發）
 func (p *Player) handleGameReset(room *Room) error {
-	if !p.IsHost {
-		return errors.New(ErrCodeNotHost)
+	if !p.IsHost && !room.Solo {
+		return NewError(ErrCodeNotHost)
 	}
 	return room.GameReset()
 }
 
-// ResetGame 重置玩家遊戲狀態（例如重新開始時使用）
-func (p *Player) ResetGame() {
-	p.Game = NewGame()
+// handleUpdateSettings 處理更新房間設定的請求（僅允許房主觸發）
+func (p *Player) handleUpdateSettings(patch SettingsPatch, room *Room) error {
+	if !p.IsHost {
+		return NewError(ErrCodeNotHost)
+	}
+	room.mu.Lock()
+	settings := room.Settings
+	room.mu.Unlock()
+
+	// 未提供的欄位沿用目前設定
+	if err := patch.Apply(&settings); err != nil {
+		return err
+	}
+	return room.UpdateSettings(settings)
+}
+
+// handleSetLanguage 處理玩家自選題目語言，進行中的遊戲會從下一題開始套用
+func (p *Player) handleSetLanguage(locale string, room *Room) error {
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	if err := p.SetLanguage(locale); err != nil {
+		return err
+	}
+	if p.Game != nil {
+		p.Game.SetLanguages(room.quizLanguages(p))
+	}
+	logger.Output.Info("Player %s quiz language changed to: %q", p.Name, locale)
+	return nil
+}
+
+// handleCreateChallenge 以玩家剛完成的遊戲建立挑戰，並回傳挑戰碼
+func (p *Player) handleCreateChallenge(room *Room) error {
+	if p.IsHost {
+		return NewError(ErrCodeHostCannotChallenge)
+	}
+	challenge, err := room.CreateChallenge(p)
+	if err != nil {
+		return err
+	}
+	logger.Output.Info("Player %s created challenge %s", p.Name, challenge.Code)
+	return p.Send(Message{
+		Type:    MsgTypeChallengeCreated,
+		Payload: challenge,
+	})
+}
+
+// handleAddBots 處理加入機器人的請求（僅允許房主觸發）
+func (p *Player) handleAddBots(payload AddBotsPayload, room *Room) error {
+	if !p.IsHost {
+		return NewError(ErrCodeNotHost)
+	}
+	return room.AddBots(payload)
+}
+
+// ResetGame 依房間設定重置玩家遊戲狀態（例如重新開始時使用）
+func (p *Player) ResetGame(settings RoomSettings) {
+	p.Game = NewGame(settings)
 	p.IsReady = false
 	p.Score = 0
 }
 
-// UpdateScore 根據答案正確與否更新分數與進度
-func (p *Player) UpdateScore(correct bool) {
-	if correct {
-		p.Score += 10
-		p.Game.Progress++
-	} else {
-		p.Game.WrongCount ++
-		p.Score -= 5
-	}
-	if p.Game.Progress >= p.Game.TotalQuiz {
-		p.Game.IsFinished = true
+// UpdateScore 根據計分規則與答案正確與否更新分數與進度，並回傳分數變化事件
+func (p *Player) UpdateScore(rules ScoringRules, correct bool, first bool) []ScoreEvent {
+	events := rules.Evaluate(p.Game, correct, first)
+	for i := range events {
+		p.Score += events[i].Delta
+		events[i].PlayerID = p.ID
+		events[i].Name = p.Name
+		events[i].Score = p.Score
 	}
+
+	p.Game.advance(correct)
+	return events
 }
 
 // Close 安全地關閉玩家連線
@@ -0,0 +1,157 @@
+package game
+
+import "time"
+
+// DefaultBroadcastInterval 作答後玩家列表更新的預設合併間隔
+const DefaultBroadcastInterval = 100 * time.Millisecond
+
+// BroadcastInterval 作答造成的玩家列表更新在此間隔內合併為一次廣播，0 表示每次作答立即廣播
+var BroadcastInterval = DefaultBroadcastInterval
+
+// 啟用差異更新的連線每收到這麼多次玩家列表更新，就改發一次完整列表以修正可能的偏差
+const playerListFullEvery = 20
+
+// 玩家列表差異更新的功能名稱，客戶端在 hello 中宣告後啟用
+const FeaturePlayerListDelta = "player_list_delta"
+
+// PlayerListDelta 與上一次玩家列表相比的變化：只列出有變化的玩家與欄位，以及已離開的玩家
+type PlayerListDelta struct {
+	Changed []PlayerListDiff `json:"changed,omitempty"`
+	Removed []string         `json:"removed,omitempty"`
+}
+
+// PlayerListDiff 單一玩家有變化的欄位，未變化的欄位省略；新加入的玩家會帶上所有欄位
+type PlayerListDiff struct {
+	ID          string  `json:"id"`
+	Name        *string `json:"name,omitempty"`
+	IsReady     *bool   `json:"isReady,omitempty"`
+	IsSpectator *bool   `json:"isSpectator,omitempty"`
+	IsBot       *bool   `json:"isBot,omitempty"`
+	Progress    *int    `json:"progress,omitempty"`
+	WrongCount  *int    `json:"wrongCount,omitempty"`
+	Score       *int    `json:"score,omitempty"`
+	Rank        *int    `json:"rank,omitempty"`
+	TotalScore  *int    `json:"totalScore,omitempty"`
+}
+
+// 比較同一位玩家前後兩次的資訊；欄位由有變無（例如比賽重置後的總分）無法以差異表示，返回 false
+func diffPlayerListEntry(prev, next PlayerListEntry) (PlayerListDiff, bool, bool) {
+	diff := PlayerListDiff{ID: next.ID}
+	changed := false
+	if prev.Name != next.Name {
+		diff.Name, changed = &next.Name, true
+	}
+	if prev.IsReady != next.IsReady {
+		diff.IsReady, changed = &next.IsReady, true
+	}
+	if prev.IsSpectator != next.IsSpectator {
+		diff.IsSpectator, changed = &next.IsSpectator, true
+	}
+	if prev.IsBot != next.IsBot {
+		diff.IsBot, changed = &next.IsBot, true
+	}
+	if prev.Progress != next.Progress {
+		diff.Progress, changed = &next.Progress, true
+	}
+	if prev.WrongCount != next.WrongCount {
+		diff.WrongCount, changed = &next.WrongCount, true
+	}
+	if prev.Score != next.Score {
+		diff.Score, changed = &next.Score, true
+	}
+	if prev.Rank != next.Rank {
+		diff.Rank, changed = &next.Rank, true
+	}
+	switch {
+	case prev.TotalScore != nil && next.TotalScore == nil:
+		return diff, false, false
+	case next.TotalScore != nil && (prev.TotalScore == nil || *prev.TotalScore != *next.TotalScore):
+		diff.TotalScore, changed = next.TotalScore, true
+	}
+	return diff, changed, true
+}
+
+// 新加入的玩家以所有欄位表示
+func fullPlayerListDiff(entry PlayerListEntry) PlayerListDiff {
+	return PlayerListDiff{
+		ID:          entry.ID,
+		Name:        &entry.Name,
+		IsReady:     &entry.IsReady,
+		IsSpectator: &entry.IsSpectator,
+		IsBot:       &entry.IsBot,
+		Progress:    &entry.Progress,
+		WrongCount:  &entry.WrongCount,
+		Score:       &entry.Score,
+		Rank:        &entry.Rank,
+		TotalScore:  entry.TotalScore,
+	}
+}
+
+// 計算與上一次廣播的玩家列表的差異並記錄本次列表，需要改發完整列表時返回 false，呼叫時需持有 r.mu
+func (r *Room) playerListDeltaLocked(list []PlayerListEntry) (PlayerListDelta, bool) {
+	prev := r.lastPlayerList
+	r.lastPlayerList = make(map[string]PlayerListEntry, len(list))
+	for _, entry := range list {
+		r.lastPlayerList[entry.ID] = entry
+	}
+	r.playerListUpdates++
+	if prev == nil || r.playerListUpdates%playerListFullEvery == 0 {
+		return PlayerListDelta{}, false
+	}
+
+	var delta PlayerListDelta
+	for _, entry := range list {
+		old, ok := prev[entry.ID]
+		if !ok {
+			delta.Changed = append(delta.Changed, fullPlayerListDiff(entry))
+			continue
+		}
+		diff, changed, ok := diffPlayerListEntry(old, entry)
+		if !ok {
+			return PlayerListDelta{}, false
+		}
+		if changed {
+			delta.Changed = append(delta.Changed, diff)
+		}
+	}
+	for id := range prev {
+		if _, ok := r.lastPlayerList[id]; !ok {
+			delta.Removed = append(delta.Removed, id)
+		}
+	}
+	return delta, true
+}
+
+// markPlayerListDirty 標記玩家列表需要更新，由計時器在間隔結束時合併廣播一次
+func (r *Room) markPlayerListDirty() {
+	if BroadcastInterval <= 0 {
+		r.BroadcastPlayerList()
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.broadcastTimer == nil {
+		r.broadcastTimer = time.AfterFunc(BroadcastInterval, r.flushPlayerList)
+	}
+}
+
+// 合併間隔結束，廣播最新的玩家列表；期間已立即廣播過時不再重複
+func (r *Room) flushPlayerList() {
+	r.mu.Lock()
+	pending := r.broadcastTimer != nil
+	r.broadcastTimer = nil
+	r.mu.Unlock()
+
+	if pending {
+		r.BroadcastPlayerList()
+	}
+}
+
+// 停止合併廣播的計時器，呼叫時需持有 r.mu
+func (r *Room) stopBroadcastTimerLocked() {
+	if r.broadcastTimer != nil {
+		r.broadcastTimer.Stop()
+		r.broadcastTimer = nil
+	}
+}
@@ -0,0 +1,165 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+func intPtr(v int) *int { return &v }
+
+func TestDiffPlayerListEntry(t *testing.T) {
+	base := PlayerListEntry{ID: "p1", Name: "alice", Progress: 3, Score: 30}
+
+	tests := []struct {
+		name        string
+		prev, next  PlayerListEntry
+		want        PlayerListDiff
+		wantChanged bool
+		wantOK      bool
+	}{
+		{
+			name:   "unchanged",
+			prev:   base,
+			next:   base,
+			want:   PlayerListDiff{ID: "p1"},
+			wantOK: true,
+		},
+		{
+			name: "progress and score",
+			prev: base,
+			next: PlayerListEntry{ID: "p1", Name: "alice", Progress: 4, Score: 40},
+			want: PlayerListDiff{ID: "p1", Progress: intPtr(4), Score: intPtr(40)},
+
+			wantChanged: true,
+			wantOK:      true,
+		},
+		{
+			name: "field reset to zero value",
+			prev: PlayerListEntry{ID: "p1", Name: "alice", IsReady: true, WrongCount: 2},
+			next: PlayerListEntry{ID: "p1", Name: "alice"},
+			want: PlayerListDiff{ID: "p1", IsReady: new(bool), WrongCount: intPtr(0)},
+
+			wantChanged: true,
+			wantOK:      true,
+		},
+		{
+			name: "total score added",
+			prev: base,
+			next: PlayerListEntry{ID: "p1", Name: "alice", Progress: 3, Score: 30, TotalScore: intPtr(30)},
+			want: PlayerListDiff{ID: "p1", TotalScore: intPtr(30)},
+
+			wantChanged: true,
+			wantOK:      true,
+		},
+		{
+			name:   "total score unchanged",
+			prev:   PlayerListEntry{ID: "p1", TotalScore: intPtr(30)},
+			next:   PlayerListEntry{ID: "p1", TotalScore: intPtr(30)},
+			want:   PlayerListDiff{ID: "p1"},
+			wantOK: true,
+		},
+		{
+			// 差異更新無法表示欄位被移除，需改發完整列表
+			name:   "total score removed",
+			prev:   PlayerListEntry{ID: "p1", TotalScore: intPtr(30)},
+			next:   PlayerListEntry{ID: "p1"},
+			want:   PlayerListDiff{ID: "p1"},
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, changed, ok := diffPlayerListEntry(tt.prev, tt.next)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if changed != tt.wantChanged {
+				t.Fatalf("changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if !reflect.DeepEqual(diff, tt.want) {
+				t.Fatalf("diff = %+v, want %+v", diff, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlayerListDeltaLocked(t *testing.T) {
+	alice := PlayerListEntry{ID: "a", Name: "alice"}
+	bob := PlayerListEntry{ID: "b", Name: "bob"}
+	aliceScored := PlayerListEntry{ID: "a", Name: "alice", Progress: 1, Score: 10}
+
+	steps := []struct {
+		name      string
+		list      []PlayerListEntry
+		wantDelta bool
+		want      PlayerListDelta
+	}{
+		{name: "first list is full", list: []PlayerListEntry{alice}, wantDelta: false},
+		{
+			name:      "player joined",
+			list:      []PlayerListEntry{alice, bob},
+			wantDelta: true,
+			want:      PlayerListDelta{Changed: []PlayerListDiff{fullPlayerListDiff(bob)}},
+		},
+		{
+			name:      "player scored",
+			list:      []PlayerListEntry{aliceScored, bob},
+			wantDelta: true,
+			want:      PlayerListDelta{Changed: []PlayerListDiff{{ID: "a", Progress: intPtr(1), Score: intPtr(10)}}},
+		},
+		{
+			name:      "player left",
+			list:      []PlayerListEntry{aliceScored},
+			wantDelta: true,
+			want:      PlayerListDelta{Removed: []string{"b"}},
+		},
+		{name: "nothing changed", list: []PlayerListEntry{aliceScored}, wantDelta: true},
+	}
+
+	r := NewRoom("delta")
+	for _, step := range steps {
+		delta, ok := r.playerListDeltaLocked(step.list)
+		if ok != step.wantDelta {
+			t.Fatalf("%s: ok = %v, want %v", step.name, ok, step.wantDelta)
+		}
+		if ok && !reflect.DeepEqual(delta, step.want) {
+			t.Fatalf("%s: delta = %+v, want %+v", step.name, delta, step.want)
+		}
+	}
+}
+
+func TestPlayerListDeltaLockedSendsFullListPeriodically(t *testing.T) {
+	r := NewRoom("delta")
+	list := []PlayerListEntry{{ID: "a", Name: "alice"}}
+	for i := 1; i <= playerListFullEvery; i++ {
+		_, ok := r.playerListDeltaLocked(list)
+		wantFull := i == 1 || i%playerListFullEvery == 0
+		if ok == wantFull {
+			t.Fatalf("update %d: delta ok = %v, want full list = %v", i, ok, wantFull)
+		}
+	}
+}
+
+func TestBroadcastPlayerListByCapability(t *testing.T) {
+	room := NewRoom("delta")
+	modern, modernConn := joinTestPlayer(t, room, "alice")
+	deliver(t, room, modern, `{"type":"hello","payload":{"version":2,"capabilities":["player_list_delta"]}}`)
+	_, legacyConn := joinTestPlayer(t, room, "bob")
+
+	room.BroadcastPlayerList()
+	room.BroadcastPlayerList()
+
+	// 宣告支援差異更新的連線在第一份完整列表之後只收到差異，其他連線每次都收到完整列表
+	modernTypes := messageTypes(drainMessages(t, modernConn))
+	if modernTypes[MsgTypePlayerList] != 1 || modernTypes[MsgTypePlayerListDelta] != 1 {
+		t.Fatalf("delta client got %v, want one full list and one delta", modernTypes)
+	}
+	legacyTypes := messageTypes(drainMessages(t, legacyConn))
+	if legacyTypes[MsgTypePlayerList] != 2 || legacyTypes[MsgTypePlayerListDelta] != 0 {
+		t.Fatalf("legacy client got %v, want two full lists", legacyTypes)
+	}
+}
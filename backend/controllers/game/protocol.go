@@ -0,0 +1,243 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+)
+
+// 消息的發送方
+const (
+	DirectionClient = "client"
+	DirectionServer = "server"
+)
+
+// 協定層級的錯誤碼
+const (
+	ErrCodeUnknownMessage = "unknown_message"
+	ErrCodeInvalidPayload = "invalid_payload"
+)
+
+// MessageSpec 描述一種消息類型：由誰發送、負載的型別與用途
+type MessageSpec struct {
+	Type        string
+	Direction   string
+	Description string
+	// 負載型別的零值，nil 表示此消息不帶負載
+	Payload interface{}
+}
+
+// 客戶端消息的負載
+type (
+	// ReadyPayload 玩家的準備狀態
+	ReadyPayload bool
+	// AnswerPayload 玩家選擇的顏色鍵值
+	AnswerPayload string
+	// SetLanguagePayload 玩家自選的題目語言，空字串表示使用房間設定
+	SetLanguagePayload string
+)
+
+// SettingsPatch 房主更新的部分房間設定；未提供的欄位沿用目前設定
+type SettingsPatch struct {
+	raw json.RawMessage
+}
+
+// 伺服器消息的負載
+type (
+	// ErrorPayload 統一的錯誤回應
+	ErrorPayload struct {
+		Code    string                 `json:"code"`
+		Params  map[string]interface{} `json:"params,omitempty"`
+		Message string                 `json:"message"`
+	}
+
+	// NoticePayload 給玩家看的提示文字（例如遊戲開始、遊戲結束）
+	NoticePayload string
+
+	// GameStatePayload 玩家自己的遊戲狀態
+	GameStatePayload struct {
+		Name string `json:"name"`
+		GameStatus
+	}
+
+	// PlayerListEntry 玩家列表中的一位玩家，觀戰者不參與排名
+	PlayerListEntry struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		IsReady     bool   `json:"isReady"`
+		IsSpectator bool   `json:"isSpectator,omitempty"`
+		IsBot       bool   `json:"isBot,omitempty"`
+		Progress    int    `json:"progress"`
+		WrongCount  int    `json:"wrongCount"`
+		Score       int    `json:"score"`
+		Rank        int    `json:"rank,omitempty"`
+		TotalScore  *int   `json:"totalScore,omitempty"`
+	}
+
+	// RoundEndPayload 回合結算
+	RoundEndPayload struct {
+		Round       int             `json:"round"`
+		TotalRounds int             `json:"totalRounds"`
+		Standings   []MatchStanding `json:"standings"`
+	}
+
+	// MatchEndPayload 比賽結算
+	MatchEndPayload struct {
+		TotalRounds int             `json:"totalRounds"`
+		Standings   []MatchStanding `json:"standings"`
+		Winners     []MatchStanding `json:"winners"`
+	}
+
+	// PersonalBestPayload 單人練習結束後與個人最佳紀錄的比較
+	PersonalBestPayload struct {
+		Mode      GameMode    `json:"mode"`
+		QuizCount int         `json:"quizCount"`
+		TimeLimit int         `json:"timeLimit"`
+		Current   GameRecord  `json:"current"`
+		Previous  *GameRecord `json:"previous"`
+		IsNewBest bool        `json:"isNewBest"`
+	}
+)
+
+// 所有消息類型與其負載，客戶端消息依此解碼，JSON Schema 亦由此產生
+var messageSpecs = []MessageSpec{
+	{Type: MsgTypeHello, Direction: DirectionClient, Description: "宣告協定版本與支援的功能", Payload: HelloPayload{}},
+	{Type: MsgTypeReady, Direction: DirectionClient, Description: "設定準備狀態", Payload: ReadyPayload(false)},
+	{Type: MsgTypeGameStart, Direction: DirectionClient, Description: "房主開始遊戲"},
+	{Type: MsgTypeAnswer, Direction: DirectionClient, Description: "提交答案", Payload: AnswerPayload("")},
+	{Type: MsgTypeGameReset, Direction: DirectionClient, Description: "房主重置遊戲"},
+	{Type: MsgTypeUpdateSettings, Direction: DirectionClient, Description: "房主更新房間設定", Payload: SettingsPatch{}},
+	{Type: MsgTypeSetLanguage, Direction: DirectionClient, Description: "設定個人題目語言", Payload: SetLanguagePayload("")},
+	{Type: MsgTypeCreateChallenge, Direction: DirectionClient, Description: "以剛完成的遊戲建立挑戰"},
+	{Type: MsgTypeLeaveQueue, Direction: DirectionClient, Description: "離開快速配對佇列"},
+	{Type: MsgTypeSync, Direction: DirectionClient, Description: "補齊錯過的房間事件或取得房間快照", Payload: SyncPayload{}},
+	{Type: MsgTypeAddBots, Direction: DirectionClient, Description: "房主加入機器人玩家", Payload: AddBotsPayload{}},
+	{Type: MsgTypeRemoveBots, Direction: DirectionClient, Description: "房主移出所有機器人玩家"},
+
+	{Type: MsgTypeWelcome, Direction: DirectionServer, Description: "協商後的協定版本、功能與房間設定", Payload: WelcomePayload{}},
+	{Type: MsgTypeAck, Direction: DirectionServer, Description: "指令處理成功", Payload: AckPayload{}},
+	{Type: MsgTypeError, Direction: DirectionServer, Description: "錯誤回應", Payload: ErrorPayload{}},
+	{Type: MsgTypeGameState, Direction: DirectionServer, Description: "玩家自己的遊戲狀態", Payload: GameStatePayload{}},
+	{Type: MsgTypeGameStart, Direction: DirectionServer, Description: "遊戲開始", Payload: NoticePayload("")},
+	{Type: MsgTypeGameEnd, Direction: DirectionServer, Description: "遊戲結束", Payload: NoticePayload("")},
+	{Type: MsgTypeGameReset, Direction: DirectionServer, Description: "遊戲已重置", Payload: NoticePayload("")},
+	{Type: MsgTypePlayerList, Direction: DirectionServer, Description: "玩家列表與排名", Payload: []PlayerListEntry{}},
+	{Type: MsgTypePlayerListDelta, Direction: DirectionServer, Description: "玩家列表與上一次相比的變化", Payload: PlayerListDelta{}},
+	{Type: MsgTypeRoomSettings, Direction: DirectionServer, Description: "目前的房間設定", Payload: RoomSettings{}},
+	{Type: MsgTypeScoreEvent, Direction: DirectionServer, Description: "自己的分數變化", Payload: ScoreEvent{}},
+	{Type: MsgTypeRoundEnd, Direction: DirectionServer, Description: "回合結算", Payload: RoundEndPayload{}},
+	{Type: MsgTypeMatchEnd, Direction: DirectionServer, Description: "比賽結算", Payload: MatchEndPayload{}},
+	{Type: MsgTypePersonalBest, Direction: DirectionServer, Description: "個人最佳紀錄比較", Payload: PersonalBestPayload{}},
+	{Type: MsgTypeDailyResult, Direction: DirectionServer, Description: "每日挑戰排行榜", Payload: DailyLeaderboard{}},
+	{Type: MsgTypeChallengeCreated, Direction: DirectionServer, Description: "挑戰已建立", Payload: Challenge{}},
+	{Type: MsgTypeChallengeResult, Direction: DirectionServer, Description: "與挑戰者的比較結果", Payload: ChallengeComparison{}},
+	{Type: MsgTypeTournamentState, Direction: DirectionServer, Description: "錦標賽對戰表", Payload: Tournament{}},
+	{Type: MsgTypeQueueStatus, Direction: DirectionServer, Description: "快速配對排隊狀態", Payload: QueueStatus{}},
+	{Type: MsgTypeMatchFound, Direction: DirectionServer, Description: "快速配對成功", Payload: MatchFound{}},
+	{Type: MsgTypeAutoHost, Direction: DirectionServer, Description: "自動主持狀態", Payload: AutoHostStatus{}},
+	{Type: MsgTypeReadyStatus, Direction: DirectionServer, Description: "準備狀態", Payload: ReadyStatus{}},
+	{Type: MsgTypeKicked, Direction: DirectionServer, Description: "被移出房間", Payload: NoticePayload("")},
+	{Type: MsgTypeSnapshot, Direction: DirectionServer, Description: "房間完整狀態", Payload: RoomSnapshot{}},
+}
+
+// 可由客戶端發送的消息類型
+var clientMessages = func() map[string]MessageSpec {
+	specs := make(map[string]MessageSpec)
+	for _, spec := range messageSpecs {
+		if spec.Direction == DirectionClient {
+			specs[spec.Type] = spec
+		}
+	}
+	return specs
+}()
+
+// MessageSpecs 返回所有已登記的消息類型
+func MessageSpecs() []MessageSpec {
+	return append([]MessageSpec(nil), messageSpecs...)
+}
+
+// 解析 JSON 消息外層，負載保留原始 JSON
+func parseEnvelope(data []byte) (Envelope, error) {
+	var env Envelope
+	if err := strictUnmarshal(data, &env); err != nil {
+		return env, NewError(ErrCodeInvalidMessage)
+	}
+	if err := validateRequestID(env.ID); err != nil {
+		env.ID = ""
+		return env, err
+	}
+	return env, nil
+}
+
+// 依消息類型嚴格解碼負載：未知的消息類型、多餘的欄位（hello 除外）或型別不符皆視為錯誤
+// 返回的負載為對應型別的指標，不帶負載的消息返回 nil
+func decodeEnvelopePayload(env Envelope) (interface{}, error) {
+	spec, ok := clientMessages[env.Type]
+	if !ok {
+		return nil, NewError(ErrCodeUnknownMessage).With("type", env.Type)
+	}
+	if spec.Payload == nil {
+		// 不帶負載的消息允許省略、null 或空物件
+		if !isEmptyPayload(env.Payload) {
+			return nil, NewError(ErrCodeInvalidPayload).With("type", env.Type).With("detail", "payload not allowed")
+		}
+		return nil, nil
+	}
+
+	payload := reflect.New(reflect.TypeOf(spec.Payload)).Interface()
+	if len(env.Payload) == 0 {
+		return nil, NewError(ErrCodeInvalidPayload).With("type", env.Type).With("detail", "payload required")
+	}
+	unmarshal := strictUnmarshal
+	if env.Type == MsgTypeHello {
+		// hello 允許未知欄位，新版客戶端可以擴充宣告內容而不被舊版伺服器拒絕
+		unmarshal = json.Unmarshal
+	}
+	if err := unmarshal(env.Payload, payload); err != nil {
+		return nil, NewError(ErrCodeInvalidPayload).With("type", env.Type).With("detail", err.Error())
+	}
+	return payload, nil
+}
+
+// 不允許未知欄位的 JSON 解碼
+func strictUnmarshal(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return errors.New("多餘的資料")
+	}
+	return nil
+}
+
+// 負載是否為空（省略、null 或 {}）
+func isEmptyPayload(raw json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(raw)
+	return len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) || bytes.Equal(trimmed, []byte("{}"))
+}
+
+// UnmarshalJSON 僅接受房間設定中存在的欄位，並保留原始內容待套用
+func (s *SettingsPatch) UnmarshalJSON(data []byte) error {
+	var probe RoomSettings
+	if err := strictUnmarshal(data, &probe); err != nil {
+		return err
+	}
+	s.raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// Apply 將部分設定套用到目前設定上；自訂調色盤需整組提供，不與舊設定合併
+func (s SettingsPatch) Apply(settings *RoomSettings) error {
+	current := settings.Palette
+	settings.Palette = Palette{}
+	if err := json.Unmarshal(s.raw, settings); err != nil {
+		return NewError(ErrCodeInvalidSettings)
+	}
+	if settings.PalettePreset == PalettePresetCustom && len(settings.Palette.Colors) == 0 {
+		settings.Palette = current
+	}
+	return settings.resolvePalette()
+}
@@ -0,0 +1,100 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeEnvelopePayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     string
+		want    interface{}
+		wantErr string
+	}{
+		{name: "ready", msg: `{"type":"ready","payload":true}`, want: func() *ReadyPayload { p := ReadyPayload(true); return &p }()},
+		{name: "answer", msg: `{"type":"answer","payload":"red"}`, want: func() *AnswerPayload { p := AnswerPayload("red"); return &p }()},
+		{name: "no payload", msg: `{"type":"game_start"}`},
+		{name: "empty object for no payload", msg: `{"type":"game_start","payload":{}}`},
+		{name: "unknown type", msg: `{"type":"dance"}`, wantErr: ErrCodeUnknownMessage},
+		{name: "server-only type", msg: `{"type":"welcome","payload":{}}`, wantErr: ErrCodeUnknownMessage},
+		{name: "wrong payload type", msg: `{"type":"ready","payload":"yes"}`, wantErr: ErrCodeInvalidPayload},
+		{name: "missing payload", msg: `{"type":"answer"}`, wantErr: ErrCodeInvalidPayload},
+		{name: "unexpected payload", msg: `{"type":"game_start","payload":{"now":true}}`, wantErr: ErrCodeInvalidPayload},
+		{name: "unknown field", msg: `{"type":"sync","payload":{"since":1,"extra":true}}`, wantErr: ErrCodeInvalidPayload},
+		{name: "unknown settings field", msg: `{"type":"update_settings","payload":{"quizCount":5,"speed":2}}`, wantErr: ErrCodeInvalidPayload},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := parseEnvelope([]byte(tt.msg))
+			if err != nil {
+				t.Fatalf("parse envelope: %v", err)
+			}
+			payload, err := decodeEnvelopePayload(env)
+			if tt.wantErr != "" {
+				if !errors.Is(err, NewError(tt.wantErr)) {
+					t.Fatalf("err = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.want != nil && !reflect.DeepEqual(payload, tt.want) {
+				t.Fatalf("payload = %#v, want %#v", payload, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEnvelopeRejectsUnknownFields(t *testing.T) {
+	if _, err := parseEnvelope([]byte(`{"type":"ready","payload":true,"extra":1}`)); !errors.Is(err, NewError(ErrCodeInvalidMessage)) {
+		t.Fatalf("err = %v, want %s", err, ErrCodeInvalidMessage)
+	}
+	if _, err := parseEnvelope([]byte(`{"type":"ready"} {}`)); !errors.Is(err, NewError(ErrCodeInvalidMessage)) {
+		t.Fatalf("trailing data: err = %v, want %s", err, ErrCodeInvalidMessage)
+	}
+}
+
+func TestSettingsPatchApply(t *testing.T) {
+	var patch SettingsPatch
+	if err := json.Unmarshal([]byte(`{"quizCount":25}`), &patch); err != nil {
+		t.Fatalf("decode patch: %v", err)
+	}
+	settings := DefaultRoomSettings()
+	settings.Rounds = 3
+	if err := patch.Apply(&settings); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	// 未提供的欄位沿用目前設定
+	if settings.QuizCount != 25 || settings.Rounds != 3 || len(settings.Palette.Colors) == 0 {
+		t.Fatalf("settings = %+v, want quiz count 25 with other fields kept", settings)
+	}
+}
+
+func TestProtocolSchemaIsUpToDate(t *testing.T) {
+	// 前端使用的 JSON Schema 需與目前的消息定義一致，修改協定後以 cmd/protocol-schema 重新產生
+	data, err := os.ReadFile("../../../frontend/src/types/protocol.schema.json")
+	if err != nil {
+		t.Skipf("frontend schema not available: %v", err)
+	}
+	var checkedIn interface{}
+	if err := json.Unmarshal(data, &checkedIn); err != nil {
+		t.Fatalf("decode checked-in schema: %v", err)
+	}
+	generated, err := json.Marshal(ProtocolSchema())
+	if err != nil {
+		t.Fatalf("encode schema: %v", err)
+	}
+	var current interface{}
+	if err := json.Unmarshal(generated, &current); err != nil {
+		t.Fatalf("decode generated schema: %v", err)
+	}
+	if !reflect.DeepEqual(checkedIn, current) {
+		t.Fatal("frontend/src/types/protocol.schema.json is out of date, run go run ./cmd/protocol-schema -o ../frontend/src/types/protocol.schema.json")
+	}
+}
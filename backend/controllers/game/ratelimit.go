@@ -0,0 +1,231 @@
+package game
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rejxcy/logger"
+)
+
+// 超過速率限制時的處理：先丟棄消息，累積一定次數後警告，再繼續濫發則斷線
+const (
+	rateActionAllow = iota
+	rateActionDrop
+	rateActionWarn
+	rateActionDisconnect
+)
+
+// RateLimit 令牌桶參數：每秒補充 Rate 個令牌，最多累積 Burst 個
+type RateLimit struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+// RateLimitConfig 每條連線的速率限制：整體限制與各消息類型的限制需同時滿足
+// 在 Window 內被丟棄的消息達 WarnAfter 則時回覆警告，達 DisconnectAfter 則時斷線
+type RateLimitConfig struct {
+	Connection      RateLimit
+	Messages        map[string]RateLimit
+	WarnAfter       int
+	DisconnectAfter int
+	Window          time.Duration
+}
+
+// DefaultRateLimitConfig 返回預設的速率限制；正常遊玩的點擊速度遠低於答案的限制
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		Connection: RateLimit{Rate: 20, Burst: 40},
+		Messages: map[string]RateLimit{
+			MsgTypeAnswer:          {Rate: 10, Burst: 20},
+			MsgTypeReady:           {Rate: 2, Burst: 5},
+			MsgTypeHello:           {Rate: 1, Burst: 3},
+			MsgTypeSync:            {Rate: 2, Burst: 5},
+			MsgTypeUpdateSettings:  {Rate: 2, Burst: 5},
+			MsgTypeSetLanguage:     {Rate: 2, Burst: 5},
+			MsgTypeCreateChallenge: {Rate: 1, Burst: 3},
+			MsgTypeAddBots:         {Rate: 1, Burst: 5},
+		},
+		WarnAfter:       5,
+		DisconnectAfter: 50,
+		Window:          10 * time.Second,
+	}
+}
+
+// Validate 檢查速率限制是否合法
+func (c RateLimitConfig) Validate() error {
+	limits := []RateLimit{c.Connection}
+	for _, l := range c.Messages {
+		limits = append(limits, l)
+	}
+	for _, l := range limits {
+		if l.Rate <= 0 || l.Burst < 1 {
+			return NewError(ErrCodeInvalidSettings)
+		}
+	}
+	if c.WarnAfter < 1 || c.DisconnectAfter < c.WarnAfter || c.Window <= 0 {
+		return NewError(ErrCodeInvalidSettings)
+	}
+	return nil
+}
+
+// 之後建立的連線使用的速率限制
+var (
+	rateLimitConfig   = DefaultRateLimitConfig()
+	rateLimitConfigMu sync.Mutex
+)
+
+// ConfigureRateLimits 設定之後建立的連線使用的速率限制
+func ConfigureRateLimits(config RateLimitConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	rateLimitConfigMu.Lock()
+	rateLimitConfig = config
+	rateLimitConfigMu.Unlock()
+	return nil
+}
+
+// 令牌桶
+type tokenBucket struct {
+	limit  RateLimit
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit RateLimit, now time.Time) *tokenBucket {
+	return &tokenBucket{limit: limit, tokens: float64(limit.Burst), last: now}
+}
+
+// 補充經過時間的令牌，是否還有令牌可用
+func (b *tokenBucket) refill(now time.Time) bool {
+	b.tokens += now.Sub(b.last).Seconds() * b.limit.Rate
+	if max := float64(b.limit.Burst); b.tokens > max {
+		b.tokens = max
+	}
+	b.last = now
+	return b.tokens >= 1
+}
+
+// 每條連線的速率限制狀態，只由該連線的讀取循環存取，不需要加鎖
+type rateLimiter struct {
+	config      RateLimitConfig
+	connection  *tokenBucket
+	messages    map[string]*tokenBucket
+	violations  int
+	windowStart time.Time
+	// 已因濫發而要求斷線，之後收到的消息一律丟棄
+	disconnected bool
+}
+
+func newRateLimiter() *rateLimiter {
+	rateLimitConfigMu.Lock()
+	config := rateLimitConfig
+	rateLimitConfigMu.Unlock()
+	return &rateLimiter{
+		config:     config,
+		connection: newTokenBucket(config.Connection, time.Now()),
+		messages:   make(map[string]*tokenBucket),
+	}
+}
+
+// 判斷是否處理一則消息；整體與該類型的令牌皆足夠時才扣除，否則依違規次數決定處理方式
+func (l *rateLimiter) check(msgType string, now time.Time) int {
+	ok := l.connection.refill(now)
+	bucket := l.messages[msgType]
+	if limit, limited := l.config.Messages[msgType]; limited && bucket == nil {
+		bucket = newTokenBucket(limit, now)
+		l.messages[msgType] = bucket
+	}
+	if bucket != nil && !bucket.refill(now) {
+		ok = false
+	}
+	if ok {
+		l.connection.tokens--
+		if bucket != nil {
+			bucket.tokens--
+		}
+		return rateActionAllow
+	}
+
+	if now.Sub(l.windowStart) > l.config.Window {
+		l.windowStart = now
+		l.violations = 0
+	}
+	l.violations++
+	switch {
+	case l.violations >= l.config.DisconnectAfter:
+		return rateActionDisconnect
+	case l.violations == l.config.WarnAfter:
+		return rateActionWarn
+	}
+	return rateActionDrop
+}
+
+// RateLimitStats 速率限制的累計次數，供監控使用
+type RateLimitStats struct {
+	Dropped       uint64            `json:"dropped"`
+	Warned        uint64            `json:"warned"`
+	Disconnected  uint64            `json:"disconnected"`
+	DroppedByType map[string]uint64 `json:"droppedByType"`
+}
+
+var (
+	rateLimitStats   = RateLimitStats{DroppedByType: make(map[string]uint64)}
+	rateLimitStatsMu sync.Mutex
+)
+
+// GetRateLimitStats 返回速率限制累計次數的副本
+func GetRateLimitStats() RateLimitStats {
+	rateLimitStatsMu.Lock()
+	defer rateLimitStatsMu.Unlock()
+	stats := rateLimitStats
+	stats.DroppedByType = make(map[string]uint64, len(rateLimitStats.DroppedByType))
+	for t, n := range rateLimitStats.DroppedByType {
+		stats.DroppedByType[t] = n
+	}
+	return stats
+}
+
+func recordRateLimit(msgType string, action int) {
+	rateLimitStatsMu.Lock()
+	defer rateLimitStatsMu.Unlock()
+	rateLimitStats.Dropped++
+	rateLimitStats.DroppedByType[msgType]++
+	switch action {
+	case rateActionWarn:
+		rateLimitStats.Warned++
+	case rateActionDisconnect:
+		rateLimitStats.Disconnected++
+	}
+}
+
+// allowMessage 檢查玩家的消息是否在速率限制內，超過時丟棄並依違規次數警告或斷線，返回是否處理該消息
+func (p *Player) allowMessage(env Envelope) bool {
+	if p.limiter.disconnected {
+		return false
+	}
+	action := p.limiter.check(env.Type, time.Now())
+	if action == rateActionAllow {
+		return true
+	}
+	recordRateLimit(env.Type, action)
+
+	switch action {
+	case rateActionWarn:
+		logger.Output.Error("Player %s exceeded the rate limit for %s", p.Name, env.Type)
+		p.replyError(env.ID, NewError(ErrCodeRateLimited).With("type", env.Type))
+	case rateActionDisconnect:
+		logger.Output.Error("Player %s kept flooding after the warning, disconnecting", p.Name)
+		p.limiter.disconnected = true
+		p.closeWithCode(websocket.ClosePolicyViolation, "rate limit exceeded")
+	}
+	return false
+}
+
+// 以指定的關閉碼通知客戶端關閉連線
+func (p *Player) closeWithCode(code int, reason string) {
+	if p.Conn != nil {
+		p.Conn.CloseWithCode(code, reason)
+	}
+}
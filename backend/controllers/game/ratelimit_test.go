@@ -0,0 +1,174 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestRateLimitConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*RateLimitConfig)
+		wantErr bool
+	}{
+		{name: "default", modify: func(*RateLimitConfig) {}},
+		{name: "no connection rate", modify: func(c *RateLimitConfig) { c.Connection.Rate = 0 }, wantErr: true},
+		{name: "no message burst", modify: func(c *RateLimitConfig) { c.Messages[MsgTypeAnswer] = RateLimit{Rate: 1} }, wantErr: true},
+		{name: "disconnect before warning", modify: func(c *RateLimitConfig) { c.DisconnectAfter = c.WarnAfter - 1 }, wantErr: true},
+		{name: "no window", modify: func(c *RateLimitConfig) { c.Window = 0 }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultRateLimitConfig()
+			tt.modify(&config)
+			if err := config.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRateLimiterCheck(t *testing.T) {
+	config := RateLimitConfig{
+		Connection:      RateLimit{Rate: 10, Burst: 4},
+		Messages:        map[string]RateLimit{MsgTypeReady: {Rate: 1, Burst: 2}},
+		WarnAfter:       2,
+		DisconnectAfter: 3,
+		Window:          time.Second,
+	}
+	start := time.Unix(0, 0)
+
+	type call struct {
+		msgType string
+		after   time.Duration
+		want    int
+	}
+	tests := []struct {
+		name  string
+		calls []call
+	}{
+		{
+			name: "connection burst",
+			calls: []call{
+				{MsgTypeAnswer, 0, rateActionAllow},
+				{MsgTypeAnswer, 0, rateActionAllow},
+				{MsgTypeAnswer, 0, rateActionAllow},
+				{MsgTypeAnswer, 0, rateActionAllow},
+				{MsgTypeAnswer, 0, rateActionDrop},
+			},
+		},
+		{
+			name: "connection refills over time",
+			calls: []call{
+				{MsgTypeAnswer, 0, rateActionAllow},
+				{MsgTypeAnswer, 0, rateActionAllow},
+				{MsgTypeAnswer, 0, rateActionAllow},
+				{MsgTypeAnswer, 0, rateActionAllow},
+				{MsgTypeAnswer, 100 * time.Millisecond, rateActionAllow},
+				{MsgTypeAnswer, 0, rateActionDrop},
+			},
+		},
+		{
+			name: "fractional refill",
+			calls: []call{
+				{MsgTypeAnswer, 0, rateActionAllow},
+				{MsgTypeAnswer, 0, rateActionAllow},
+				{MsgTypeAnswer, 0, rateActionAllow},
+				{MsgTypeAnswer, 0, rateActionAllow},
+				{MsgTypeAnswer, 50 * time.Millisecond, rateActionDrop},
+				{MsgTypeAnswer, 100 * time.Millisecond, rateActionAllow},
+			},
+		},
+		{
+			name: "message type limit",
+			calls: []call{
+				{MsgTypeReady, 0, rateActionAllow},
+				{MsgTypeReady, 0, rateActionAllow},
+				{MsgTypeReady, 0, rateActionDrop},
+				// 其他類型只受整體限制
+				{MsgTypeAnswer, 0, rateActionAllow},
+			},
+		},
+		{
+			name: "warn then disconnect",
+			calls: []call{
+				{MsgTypeReady, 0, rateActionAllow},
+				{MsgTypeReady, 0, rateActionAllow},
+				{MsgTypeReady, 0, rateActionDrop},
+				{MsgTypeReady, 0, rateActionWarn},
+				{MsgTypeReady, 0, rateActionDisconnect},
+			},
+		},
+		{
+			name: "violations reset after window",
+			calls: []call{
+				{MsgTypeReady, 0, rateActionAllow},
+				{MsgTypeReady, 0, rateActionAllow},
+				{MsgTypeReady, 0, rateActionDrop},
+				{MsgTypeReady, 1100 * time.Millisecond, rateActionAllow},
+				{MsgTypeReady, 0, rateActionDrop},
+				{MsgTypeReady, 0, rateActionWarn},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := &rateLimiter{
+				config:     config,
+				connection: newTokenBucket(config.Connection, start),
+				messages:   make(map[string]*tokenBucket),
+			}
+			now := start
+			for i, c := range tt.calls {
+				now = now.Add(c.after)
+				if got := limiter.check(c.msgType, now); got != c.want {
+					t.Fatalf("call %d (%s): action = %d, want %d", i, c.msgType, got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestFloodingPlayerIsWarnedThenDisconnected(t *testing.T) {
+	config := DefaultRateLimitConfig()
+	config.Messages = map[string]RateLimit{MsgTypeReady: {Rate: 0.001, Burst: 1}}
+	config.WarnAfter = 1
+	config.DisconnectAfter = 2
+	if err := ConfigureRateLimits(config); err != nil {
+		t.Fatalf("configure rate limits: %v", err)
+	}
+	t.Cleanup(func() { ConfigureRateLimits(DefaultRateLimitConfig()) })
+
+	room := NewRoom("flood")
+	player, conn := joinTestPlayer(t, room, "alice")
+	drainMessages(t, conn)
+
+	// 第一則消息用完令牌，第二則超出限制時回覆警告
+	deliver(t, room, player, `{"type":"ready","payload":true}`)
+	drainMessages(t, conn)
+	deliver(t, room, player, `{"type":"ready","payload":false,"id":"r2"}`)
+	msgs := drainMessages(t, conn)
+	if len(msgs) != 1 || msgs[0].Type != MsgTypeError {
+		t.Fatalf("messages = %v, want one rate limit error", messageTypes(msgs))
+	}
+	var payload ErrorPayload
+	if err := json.Unmarshal(msgs[0].Payload, &payload); err != nil {
+		t.Fatalf("decode error payload: %v", err)
+	}
+	if payload.Code != ErrCodeRateLimited {
+		t.Fatalf("error code = %s, want %s", payload.Code, ErrCodeRateLimited)
+	}
+
+	// 警告後繼續濫發則以 policy violation 關閉連線，之後的消息不再處理
+	deliver(t, room, player, `{"type":"ready","payload":false}`)
+	if code, _ := conn.CloseCode(); code != websocket.ClosePolicyViolation {
+		t.Fatalf("close code = %d, want %d", code, websocket.ClosePolicyViolation)
+	}
+	if !player.IsReady {
+		t.Fatal("dropped messages from a flooder were processed")
+	}
+}
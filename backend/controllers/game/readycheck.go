@@ -0,0 +1,193 @@
+package game
+
+import (
+	"time"
+
+	"github.com/rejxcy/logger"
+)
+
+// 準備檢查相關常數
+const (
+	DefaultReadyPercent = 100
+	MaxReadyTimeout     = 300
+)
+
+// 準備逾時的處理方式，空字串表示不處理（此時不會開始計時）
+const (
+	ReadyTimeoutNone     = ""
+	ReadyTimeoutSpectate = "spectate"
+	ReadyTimeoutKick     = "kick"
+)
+
+// 準備狀態，玩家加入、離開或改變準備狀態時廣播
+type ReadyStatus struct {
+	Ready            int        `json:"ready"`
+	Total            int        `json:"total"`
+	Required         int        `json:"required"`
+	Percent          int        `json:"percent"`
+	CanStart         bool       `json:"canStart"`
+	Deadline         *time.Time `json:"deadline,omitempty"`
+	RemainingSeconds int        `json:"remainingSeconds"`
+	OnTimeout        string     `json:"onTimeout"`
+}
+
+// DefaultReadyCheckRules 預設需要所有玩家準備，且不設定逾時
+func DefaultReadyCheckRules() ReadyCheckRules {
+	return ReadyCheckRules{
+		ReadyPercent: DefaultReadyPercent,
+	}
+}
+
+// Validate 檢查準備規則是否在允許範圍內
+func (r ReadyCheckRules) Validate() error {
+	if r.ReadyPercent < 0 || r.ReadyPercent > 100 {
+		return NewError(ErrCodeInvalidSettings)
+	}
+	if r.TimeoutSeconds < 0 || r.TimeoutSeconds > MaxReadyTimeout {
+		return NewError(ErrCodeInvalidSettings)
+	}
+	switch r.OnTimeout {
+	case ReadyTimeoutNone, ReadyTimeoutSpectate, ReadyTimeoutKick:
+	default:
+		return NewError(ErrCodeInvalidSettings)
+	}
+	return nil
+}
+
+// 依準備比例計算開始所需的準備人數（無條件進位）
+func (r ReadyCheckRules) required(total int) int {
+	return (r.ReadyPercent*total + 99) / 100
+}
+
+// 是否為參與作答與排名的玩家（非房主、非觀戰者）
+func (p *Player) isCompetitor() bool {
+	return !p.IsHost && !p.IsSpectator
+}
+
+// 是否列入排名與比賽結算的玩家
+func (p *Player) isRanked() bool {
+	return p.isCompetitor() && !p.unranked
+}
+
+// 計算已準備與參賽玩家人數，呼叫時需持有 r.mu
+func (r *Room) readyCountsLocked() (ready, total int) {
+	for _, p := range r.Players {
+		if !p.isCompetitor() {
+			continue
+		}
+		total++
+		if p.IsReady {
+			ready++
+		}
+	}
+	return ready, total
+}
+
+// 是否達到房間設定的準備比例（至少需要一位參賽玩家），呼叫時需持有 r.mu
+func (r *Room) readyCheckMetLocked() bool {
+	ready, total := r.readyCountsLocked()
+	return total >= MinPlayers && ready >= r.Settings.ReadyCheck.required(total)
+}
+
+// 目前的準備狀態，呼叫時需持有 r.mu
+func (r *Room) readyStatusLocked(now time.Time) ReadyStatus {
+	ready, total := r.readyCountsLocked()
+	rules := r.Settings.ReadyCheck
+	status := ReadyStatus{
+		Ready:     ready,
+		Total:     total,
+		Required:  rules.required(total),
+		Percent:   rules.ReadyPercent,
+		CanStart:  r.readyCheckMetLocked(),
+		OnTimeout: rules.OnTimeout,
+	}
+	if !r.readyDeadline.IsZero() {
+		deadline := r.readyDeadline
+		status.Deadline = &deadline
+		status.RemainingSeconds = int(deadline.Sub(now).Round(time.Second) / time.Second)
+		if status.RemainingSeconds < 0 {
+			status.RemainingSeconds = 0
+		}
+	}
+	return status
+}
+
+// checkReady 在玩家加入、離開或改變準備狀態後更新準備逾時並廣播準備狀態
+// 有玩家準備而其他人尚未準備時開始計時，全部準備或無人準備時取消計時
+func (r *Room) checkReady() {
+	if r.Solo {
+		return
+	}
+
+	r.mu.Lock()
+	if r.Status != RoomStatusWaiting {
+		r.stopReadyTimerLocked()
+		r.mu.Unlock()
+		r.checkAutoHost()
+		return
+	}
+
+	now := time.Now()
+	ready, total := r.readyCountsLocked()
+	rules := r.Settings.ReadyCheck
+	switch {
+	case rules.TimeoutSeconds == 0 || rules.OnTimeout == ReadyTimeoutNone || ready == 0 || ready == total:
+		r.stopReadyTimerLocked()
+	case r.readyDeadline.IsZero():
+		d := time.Duration(rules.TimeoutSeconds) * time.Second
+		r.readyDeadline = now.Add(d)
+		r.readyTimer = time.AfterFunc(d, r.readyTimeout)
+	}
+	status := r.readyStatusLocked(now)
+	r.mu.Unlock()
+
+	r.Broadcast(Message{Type: MsgTypeReadyStatus, Payload: status})
+	r.checkAutoHost()
+}
+
+// 準備逾時：依規則將尚未準備的玩家轉為觀戰或踢出房間
+func (r *Room) readyTimeout() {
+	r.mu.Lock()
+	if r.Status != RoomStatusWaiting || r.readyDeadline.IsZero() {
+		r.mu.Unlock()
+		return
+	}
+	r.stopReadyTimerLocked()
+
+	action := r.Settings.ReadyCheck.OnTimeout
+	kicked := make([]*Player, 0)
+	for id, p := range r.Players {
+		if !p.isCompetitor() || p.IsReady {
+			continue
+		}
+		switch action {
+		case ReadyTimeoutSpectate:
+			p.IsSpectator = true
+			logger.Output.Info("玩家 %s 準備逾時，轉為觀戰", p.Name)
+		case ReadyTimeoutKick:
+			delete(r.Players, id)
+			kicked = append(kicked, p)
+			logger.Output.Info("玩家 %s 準備逾時，移出房間 %s", p.Name, r.ID)
+		}
+	}
+	r.mu.Unlock()
+
+	// 被踢出的玩家關閉連線後由消息循環負責後續清理
+	for _, p := range kicked {
+		if err := p.Send(Message{Type: MsgTypeKicked, Payload: NoticePayload("準備逾時")}); err != nil {
+			logger.Output.Error("發送踢出通知給 %s 失敗: %v", p.Name, err)
+		}
+		p.Close()
+	}
+	r.BroadcastPlayerList()
+	r.checkReady()
+}
+
+// 停止準備逾時計時器，呼叫時需持有 r.mu
+func (r *Room) stopReadyTimerLocked() {
+	if r.readyTimer != nil {
+		r.readyTimer.Stop()
+		r.readyTimer = nil
+	}
+	r.readyDeadline = time.Time{}
+}
@@ -0,0 +1,111 @@
+package game
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReadyCheckRequired(t *testing.T) {
+	tests := []struct {
+		percent int
+		total   int
+		want    int
+	}{
+		{percent: 100, total: 4, want: 4},
+		{percent: 75, total: 4, want: 3},
+		{percent: 50, total: 3, want: 2},
+		{percent: 1, total: 5, want: 1},
+		{percent: 0, total: 5, want: 0},
+	}
+	for _, tt := range tests {
+		if got := (ReadyCheckRules{ReadyPercent: tt.percent}).required(tt.total); got != tt.want {
+			t.Errorf("required(%d%% of %d) = %d, want %d", tt.percent, tt.total, got, tt.want)
+		}
+	}
+}
+
+func TestReadyCheckRulesValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   ReadyCheckRules
+		wantErr bool
+	}{
+		{name: "default", rules: DefaultReadyCheckRules()},
+		{name: "percent over 100", rules: ReadyCheckRules{ReadyPercent: 101}, wantErr: true},
+		{name: "timeout too long", rules: ReadyCheckRules{ReadyPercent: 100, TimeoutSeconds: MaxReadyTimeout + 1}, wantErr: true},
+		{name: "unknown action", rules: ReadyCheckRules{ReadyPercent: 100, TimeoutSeconds: 30, OnTimeout: "ban"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rules.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadyPercentAllowsStart(t *testing.T) {
+	room := NewRoom("ready")
+	room.Settings.ReadyCheck = ReadyCheckRules{ReadyPercent: 50}
+	players := make([]*Player, 0, 3)
+	for _, name := range []string{"alice", "bob", "carol"} {
+		p, _ := joinTestPlayer(t, room, name)
+		players = append(players, p)
+	}
+
+	deliver(t, room, players[0], `{"type":"ready","payload":true}`)
+	if err := room.StartGame(); !errors.Is(err, NewError(ErrCodeNotReady)) {
+		t.Fatalf("one of three ready: err = %v, want %s", err, ErrCodeNotReady)
+	}
+	deliver(t, room, players[1], `{"type":"ready","payload":true}`)
+	if err := room.StartGame(); err != nil {
+		t.Fatalf("two of three ready: %v", err)
+	}
+}
+
+func TestReadyTimeout(t *testing.T) {
+	tests := []struct {
+		action string
+		// 逾時後未準備的玩家是否仍在房間、是否轉為觀戰
+		wantInRoom    bool
+		wantSpectator bool
+	}{
+		{action: ReadyTimeoutSpectate, wantInRoom: true, wantSpectator: true},
+		{action: ReadyTimeoutKick},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			room := NewRoom("ready")
+			defer room.Close()
+			room.Settings.ReadyCheck = ReadyCheckRules{ReadyPercent: 100, TimeoutSeconds: MaxReadyTimeout, OnTimeout: tt.action}
+			alice, _ := joinTestPlayer(t, room, "alice")
+			bob, bobConn := joinTestPlayer(t, room, "bob")
+
+			// 有人準備後開始計時，逾時時處理尚未準備的玩家
+			deliver(t, room, alice, `{"type":"ready","payload":true}`)
+			room.mu.Lock()
+			started := !room.readyDeadline.IsZero()
+			room.mu.Unlock()
+			if !started {
+				t.Fatal("ready timer not started")
+			}
+			room.readyTimeout()
+
+			room.mu.Lock()
+			_, inRoom := room.Players[bob.ID]
+			spectator := bob.IsSpectator
+			met := room.readyCheckMetLocked()
+			room.mu.Unlock()
+			if inRoom != tt.wantInRoom || spectator != tt.wantSpectator {
+				t.Fatalf("bob in room %v spectator %v, want %v and %v", inRoom, spectator, tt.wantInRoom, tt.wantSpectator)
+			}
+			if !met {
+				t.Fatal("remaining ready players cannot start after the timeout")
+			}
+			if kicked := messageTypes(drainMessages(t, bobConn))[MsgTypeKicked] == 1; kicked == tt.wantInRoom {
+				t.Fatalf("bob kicked notice = %v, want %v", kicked, !tt.wantInRoom)
+			}
+		})
+	}
+}
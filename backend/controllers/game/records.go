@@ -0,0 +1,79 @@
+package game
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 全域的個人最佳紀錄
+var GlobalRecordStore = NewRecordStore()
+
+// 單局成績
+type GameRecord struct {
+	Score      int       `json:"score"`
+	Progress   int       `json:"progress"`
+	WrongCount int       `json:"wrongCount"`
+	DurationMs int64     `json:"durationMs"`
+	AchievedAt time.Time `json:"achievedAt"`
+}
+
+// RecordStore 以玩家名稱、模式與長度為鍵保存個人最佳紀錄
+type RecordStore struct {
+	best map[string]GameRecord
+	mu   sync.Mutex
+}
+
+// NewRecordStore 創建新的紀錄儲存
+func NewRecordStore() *RecordStore {
+	return &RecordStore{
+		best: make(map[string]GameRecord),
+	}
+}
+
+// NewGameRecord 由玩家目前的遊戲結果建立成績
+func NewGameRecord(p *Player) GameRecord {
+	return GameRecord{
+		Score:      p.Score,
+		Progress:   p.Game.Progress,
+		WrongCount: p.Game.WrongCount,
+		DurationMs: p.Game.Duration().Milliseconds(),
+		AchievedAt: p.Game.FinishedAt,
+	}
+}
+
+// Better 判斷成績是否優於另一筆：分數高者優先，其次錯誤少、用時短
+func (rec GameRecord) Better(other GameRecord) bool {
+	if rec.Score != other.Score {
+		return rec.Score > other.Score
+	}
+	if rec.WrongCount != other.WrongCount {
+		return rec.WrongCount < other.WrongCount
+	}
+	return rec.DurationMs < other.DurationMs
+}
+
+// Submit 提交成績並返回先前的最佳紀錄，以及本次是否刷新紀錄
+func (s *RecordStore) Submit(key string, rec GameRecord) (*GameRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, ok := s.best[key]
+	if !ok {
+		s.best[key] = rec
+		return nil, true
+	}
+	if rec.Better(prev) {
+		s.best[key] = rec
+		return &prev, true
+	}
+	return &prev, false
+}
+
+// 個人紀錄的鍵值：相同模式與長度的成績才互相比較
+func recordKey(playerName string, settings RoomSettings) string {
+	if settings.Mode == GameModeTimed {
+		return fmt.Sprintf("%s|%s|%d", playerName, settings.Mode, settings.TimeLimit)
+	}
+	return fmt.Sprintf("%s|%s|%d", playerName, settings.Mode, settings.QuizCount)
+}
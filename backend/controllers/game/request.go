@@ -0,0 +1,116 @@
+package game
+
+// 請求 ID 相關常數
+const (
+	// 每位玩家平均保留的請求結果數量，房間的保留上限為此數量乘以玩家上限，超過時淘汰最舊的結果
+	requestCacheSize = 64
+	// 請求 ID 的長度上限
+	MaxRequestIDLength = 64
+)
+
+// AckPayload 指令處理成功的確認，對應的請求 ID 放在消息外層的 id
+type AckPayload struct {
+	Type string `json:"type"`
+}
+
+// 近期處理過的請求結果；客戶端重送相同 ID 的請求時直接回覆原本的結果，不會重複執行（例如答案不會重複計分）
+// 房間的快取由 r.mu 保護；玩家的快取只由該連線的讀取循環存取，不需要加鎖
+type requestCache struct {
+	results map[string]Message
+	order   []string
+	// 保留的結果數量上限，0 表示 requestCacheSize
+	limit int
+}
+
+// 取得請求 ID 已處理的回覆
+func (c *requestCache) get(id string) (Message, bool) {
+	msg, ok := c.results[id]
+	return msg, ok
+}
+
+// 記錄請求 ID 的回覆，超過保留數量時淘汰最舊的結果
+func (c *requestCache) put(id string, msg Message) {
+	if c.results == nil {
+		c.results = make(map[string]Message, requestCacheSize)
+	}
+	if _, ok := c.results[id]; !ok {
+		c.order = append(c.order, id)
+	}
+	c.results[id] = msg
+	limit := c.limit
+	if limit == 0 {
+		limit = requestCacheSize
+	}
+	if len(c.order) > limit {
+		delete(c.results, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// 檢查請求 ID 是否合法，未帶 ID 的消息不需要確認
+func validateRequestID(id string) error {
+	if len(id) > MaxRequestIDLength {
+		return NewError(ErrCodeInvalidMessage)
+	}
+	return nil
+}
+
+// 房間請求快取的鍵，玩家以重新連線憑證恢復原本的 ID 後沿用原本的結果
+func roomRequestKey(playerID, id string) string {
+	return playerID + "/" + id
+}
+
+// 取得請求已處理的回覆：在房間內時查詢房間的快取，否則查詢玩家的快取
+func (p *Player) cachedReply(id string, room *Room) (Message, bool) {
+	if room == nil {
+		return p.requests.get(id)
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	return room.requests.get(roomRequestKey(p.ID, id))
+}
+
+// 記錄請求的回覆，位置同 cachedReply
+func (p *Player) storeReply(id string, room *Room, reply Message) {
+	if room == nil {
+		p.requests.put(id, reply)
+		return
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	room.requests.put(roomRequestKey(p.ID, id), reply)
+}
+
+// handleRequest 處理帶有請求 ID 的指令：重送的請求回覆原本的結果，否則執行後以 ack 或 error 回覆並記錄結果
+// 在房間內時結果記錄在房間，斷線重連後重送的請求仍不會重複執行；未帶 ID 的指令維持原本的行為，只在失敗時回覆錯誤
+func (p *Player) handleRequest(env Envelope, room *Room, handle func() error) {
+	if env.ID != "" {
+		if reply, ok := p.cachedReply(env.ID, room); ok {
+			p.Send(reply)
+			return
+		}
+	}
+
+	err := handle()
+	if env.ID == "" {
+		if err != nil {
+			p.SendError(err)
+		}
+		return
+	}
+
+	reply := Message{ID: env.ID, Type: MsgTypeAck, Payload: AckPayload{Type: env.Type}}
+	if err != nil {
+		reply = NewErrorMessage(err, p.errorLocale())
+		reply.ID = env.ID
+	}
+	p.storeReply(env.ID, room, reply)
+	p.Send(reply)
+}
+
+// replyError 回覆無法處理的消息，帶有請求 ID 時一併附上；這類錯誤不記錄，客戶端修正後可用相同 ID 重送
+func (p *Player) replyError(id string, err error) {
+	reply := NewErrorMessage(err, p.errorLocale())
+	reply.ID = id
+	p.Send(reply)
+}
@@ -0,0 +1,156 @@
+package game
+
+import "testing"
+
+const testHello = `{"type":"hello","payload":{"version":2,"capabilities":[]}}`
+
+// 找出指定請求 ID 的回覆
+func replyFor(msgs []receivedMessage, id string) (receivedMessage, bool) {
+	for _, msg := range msgs {
+		if msg.ID == id {
+			return msg, true
+		}
+	}
+	return receivedMessage{}, false
+}
+
+func TestRequestCache(t *testing.T) {
+	ack := Message{ID: "a", Type: MsgTypeAck}
+	tests := []struct {
+		name    string
+		limit   int
+		puts    []string
+		present []string
+		evicted []string
+	}{
+		{name: "stores results", puts: []string{"a", "b"}, present: []string{"a", "b"}},
+		{name: "overwrite keeps one entry", limit: 2, puts: []string{"a", "a", "b"}, present: []string{"a", "b"}},
+		{name: "evicts oldest", limit: 2, puts: []string{"a", "b", "c"}, present: []string{"b", "c"}, evicted: []string{"a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := requestCache{limit: tt.limit}
+			for _, id := range tt.puts {
+				cache.put(id, ack)
+			}
+			for _, id := range tt.present {
+				if _, ok := cache.get(id); !ok {
+					t.Fatalf("%s missing", id)
+				}
+			}
+			for _, id := range tt.evicted {
+				if _, ok := cache.get(id); ok {
+					t.Fatalf("%s not evicted", id)
+				}
+			}
+		})
+	}
+}
+
+func TestRequestRetriedAfterResume(t *testing.T) {
+	room := NewRoom("resume")
+	room.Solo = true
+	player, conn := joinTestPlayer(t, room, "alice")
+	deliver(t, room, player, testHello)
+	if err := room.StartGame(); err != nil {
+		t.Fatalf("start game: %v", err)
+	}
+	drainMessages(t, conn)
+
+	request := `{"id":"a1","type":"answer","payload":"` + currentAnswer(room, player) + `"}`
+	deliver(t, room, player, request)
+	if reply, ok := replyFor(drainMessages(t, conn), "a1"); !ok || reply.Type != MsgTypeAck {
+		t.Fatalf("answer: reply = %+v (found %v), want ack", reply, ok)
+	}
+
+	// 斷線後帶著重新連線憑證重新加入，重送相同的請求只回覆原本的結果
+	room.RemovePlayer(player.ID)
+	conn2 := NewMemoryConn("alice")
+	rejoined := NewPlayer(conn2, "alice", false)
+	room.Resume(rejoined, player.session)
+	if rejoined.ID != player.ID {
+		t.Fatalf("resumed ID = %s, want %s", rejoined.ID, player.ID)
+	}
+	if err := room.AddPlayer(rejoined); err != nil {
+		t.Fatalf("rejoin: %v", err)
+	}
+	deliver(t, room, rejoined, testHello)
+	drainMessages(t, conn2)
+	deliver(t, room, rejoined, request)
+
+	msgs := drainMessages(t, conn2)
+	if len(msgs) != 1 || msgs[0].Type != MsgTypeAck || msgs[0].ID != "a1" {
+		t.Fatalf("retried answer: got %+v, want only the cached ack", msgs)
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	if rejoined.Game.Progress != 0 {
+		t.Fatalf("retried answer was processed again: progress %d", rejoined.Game.Progress)
+	}
+}
+
+func TestRequestCacheSeparatesPlayersWithSameName(t *testing.T) {
+	room := NewRoom("same-name")
+	first, firstConn := joinTestPlayer(t, room, "alice")
+	second, secondConn := joinTestPlayer(t, room, "alice")
+	deliver(t, room, first, testHello)
+	deliver(t, room, second, testHello)
+	if err := room.ForceStart(); err != nil {
+		t.Fatalf("start game: %v", err)
+	}
+	drainMessages(t, firstConn)
+	drainMessages(t, secondConn)
+
+	deliver(t, room, first, `{"id":"1","type":"answer","payload":"`+currentAnswer(room, first)+`"}`)
+	deliver(t, room, second, `{"id":"1","type":"answer","payload":"`+currentAnswer(room, second)+`"}`)
+
+	if types := messageTypes(drainMessages(t, secondConn)); types[MsgTypeScoreEvent] == 0 {
+		t.Fatalf("second alice: got %v, want the second answer scored", types)
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	if second.Game.Progress != 1 {
+		t.Fatalf("second alice progress = %d, want 1", second.Game.Progress)
+	}
+}
+
+func TestResumeRequiresMatchingSession(t *testing.T) {
+	room := NewRoom("resume")
+	player, _ := joinTestPlayer(t, room, "alice")
+	token := player.session
+
+	tests := []struct {
+		name   string
+		player string
+		token  string
+		leave  bool
+		resume bool
+	}{
+		{name: "unknown token", player: "alice", token: "nope", leave: true},
+		{name: "other name", player: "bob", token: token, leave: true},
+		{name: "original still connected", player: "alice", token: token},
+		{name: "resumed", player: "alice", token: token, leave: true, resume: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			room.mu.Lock()
+			room.Players[player.ID] = player
+			if tt.leave {
+				delete(room.Players, player.ID)
+			}
+			room.mu.Unlock()
+
+			p := NewPlayer(NewMemoryConn(tt.player), tt.player, false)
+			fresh := p.ID
+			room.Resume(p, tt.token)
+			if resumed := p.ID == player.ID; resumed != tt.resume {
+				t.Fatalf("resumed = %v, want %v", resumed, tt.resume)
+			}
+			if !tt.resume && p.ID != fresh {
+				t.Fatalf("ID changed to %s without resuming", p.ID)
+			}
+		})
+	}
+}
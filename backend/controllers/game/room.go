@@ -1,9 +1,9 @@
 package game
 
 import (
-	"errors"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/rejxcy/logger"
 )
@@ -11,10 +11,12 @@ import (
 // NewRoom 創建新房間並初始化內部資料結構
 func NewRoom(id string) *Room {
 	return &Room{
-		ID:        id,
-		Players:   make(map[string]*Player),
-		Status:    RoomStatusWaiting,
-		mu:        sync.Mutex{},
+		ID:       id,
+		Players:  make(map[string]*Player),
+		Status:   RoomStatusWaiting,
+		Settings: DefaultRoomSettings(),
+		requests: requestCache{limit: requestCacheSize * MaxPlayers},
+		mu:       sync.Mutex{},
 	}
 }
 
@@ -24,9 +26,18 @@ func (r *Room) AddPlayer(player *Player) error {
 	defer r.mu.Unlock()
 
 	if len(r.Players) >= MaxPlayers {
-		return errors.New(ErrCodeRoomFull)
+		return NewError(ErrCodeRoomFull)
+	}
+	if r.AllowedNames != nil && !r.AllowedNames[player.Name] {
+		return NewError(ErrCodeNotRegistered)
+	}
+	if r.Status == RoomStatusPlaying && !player.IsHost {
+		if err := r.lateJoinLocked(player, time.Now()); err != nil {
+			return err
+		}
 	}
 	r.Players[player.ID] = player
+	r.issueSessionLocked(player)
 	return nil
 }
 
@@ -38,50 +49,107 @@ func (r *Room) RemovePlayer(playerID string) {
 }
 
 // 廣播更新後的玩家列表（包含進度、錯誤數、分數與排名）給所有玩家
+// 啟用差異更新的連線在收到第一份完整列表後只收到變化的部分，事件緩衝區保留完整列表供重送
 func (r *Room) BroadcastPlayerList() {
-	// 複製所有非房主玩家資訊到 slice 中，方便進行排序
 	r.mu.Lock()
-	playersSlice := make([]*Player, 0, len(r.Players))
+	defer r.mu.Unlock()
+
+	// 立即廣播時取消尚未送出的合併更新
+	r.stopBroadcastTimerLocked()
+	rankingList := r.playerListLocked()
+	delta, ok := r.playerListDeltaLocked(rankingList)
+	msg := r.recordEventLocked(Message{
+		Type:    MsgTypePlayerList,
+		Payload: rankingList,
+	})
+	deltaMsg := Message{Seq: msg.Seq, Type: MsgTypePlayerListDelta, Payload: delta}
+
+	encoded := make(map[string][]byte)
+	deltaEncoded := make(map[string][]byte)
 	for _, p := range r.Players {
-		if p.IsHost {
+		send, cache := msg, encoded
+		if ok && p.hasPlayerList && p.HasCapability(FeaturePlayerListDelta) {
+			send, cache = deltaMsg, deltaEncoded
+		}
+		if err := p.sendEncoded(send, cache); err != nil {
+			logger.Output.Error("廣播玩家列表給 %s 失敗: %v", p.Name, err)
 			continue
 		}
-		playersSlice = append(playersSlice, p)
+		p.hasPlayerList = true
 	}
-	r.mu.Unlock()
+}
 
-	// 根據分數進行排序
-	sort.Slice(playersSlice, func(i, j int) bool {
-		if playersSlice[i].Score != playersSlice[j].Score {
-			return playersSlice[i].Score > playersSlice[j].Score // 降序排列
+// 依分數排名的玩家列表，觀戰者附在最後，呼叫時需持有 r.mu
+func (r *Room) playerListLocked() []PlayerListEntry {
+	// 複製所有非房主玩家資訊到 slice 中，方便進行排序
+	playersSlice := make([]*Player, 0, len(r.Players))
+	unranked := make([]*Player, 0)
+	spectators := make([]*Player, 0)
+	for _, p := range r.Players {
+		switch {
+		case p.IsHost:
+		case p.IsSpectator:
+			spectators = append(spectators, p)
+		case p.unranked:
+			unranked = append(unranked, p)
+		default:
+			playersSlice = append(playersSlice, p)
 		}
-		return playersSlice[i].Game.WrongCount < playersSlice[j].Game.WrongCount // 錯誤次數少者排前
-	})
+	}
 
-	// 為每位玩家分配排名，並準備要廣播給前端的資料
-	rankingList := make([]map[string]interface{}, 0, len(playersSlice))
-	for idx, p := range playersSlice {
-		rankingList = append(rankingList, map[string]interface{}{
-			"id":         p.ID,
-			"name":       p.Name,
-			"isReady":    p.IsReady,
-			"progress":   p.Game.Progress,
-			"wrongCount": p.Game.WrongCount,
-			"score":      p.Score,
-			"rank":       idx + 1,
+	// 根據分數進行排序
+	byScore := func(players []*Player) {
+		sort.Slice(players, func(i, j int) bool {
+			if players[i].Score != players[j].Score {
+				return players[i].Score > players[j].Score // 降序排列
+			}
+			return players[i].Game.WrongCount < players[j].Game.WrongCount // 錯誤次數少者排前
 		})
 	}
+	byScore(playersSlice)
+	byScore(unranked)
 
-	// 將整個玩家列表（含排名資訊）發送給所有連線的玩家
-	msg := Message{
-		Type:    MsgTypePlayerList,
-		Payload: rankingList,
-	}
-	for _, p := range r.Players {
-		if err := p.Send(msg); err != nil {
-			logger.Output.Error("廣播玩家列表給 %s 失敗: %v", p.Name, err)
+	// 為每位玩家分配排名
+	rankingList := make([]PlayerListEntry, 0, len(playersSlice)+len(spectators))
+	for idx, p := range playersSlice {
+		entry := PlayerListEntry{
+			ID:         p.ID,
+			Name:       p.Name,
+			IsReady:    p.IsReady,
+			Progress:   p.Game.Progress,
+			WrongCount: p.Game.WrongCount,
+			Score:      p.Score,
+			Rank:       idx + 1,
+			IsBot:      p.IsBot,
+		}
+		if r.Match != nil {
+			total := r.Match.TotalScores[p.ID]
+			entry.TotalScore = &total
 		}
+		rankingList = append(rankingList, entry)
+	}
+	// 不列入排名的機器人顯示作答進度但沒有名次
+	for _, p := range unranked {
+		rankingList = append(rankingList, PlayerListEntry{
+			ID:         p.ID,
+			Name:       p.Name,
+			IsReady:    p.IsReady,
+			IsBot:      true,
+			Progress:   p.Game.Progress,
+			WrongCount: p.Game.WrongCount,
+			Score:      p.Score,
+		})
+	}
+	// 觀戰者附在列表最後，不參與排名
+	for _, p := range spectators {
+		rankingList = append(rankingList, PlayerListEntry{
+			ID:          p.ID,
+			Name:        p.Name,
+			IsSpectator: true,
+			IsBot:       p.IsBot,
+		})
 	}
+	return rankingList
 }
 
 // 判斷遊戲是否已開始
@@ -92,148 +160,290 @@ func (r *Room) IsGameStarted() bool {
 }
 
 // 為所有玩家初始化獨立遊戲進度，並廣播初始狀態、遊戲開始訊息
+// 在回合間的休息狀態呼叫時會進入比賽的下一回合，否則開始一場新的比賽
 func (r *Room) StartGame() error {
 	r.mu.Lock()
-
-	// 檢查所有非房主玩家是否皆準備好
-	readyCount := 0
-	for _, p := range r.Players {
-		if !p.IsHost && p.IsReady {
-			readyCount++
-		}
+	if r.Status == RoomStatusPlaying {
+		r.mu.Unlock()
+		return NewError(ErrCodeGameInProgress)
+	}
+	// 每日挑戰只能進行一次
+	if r.Daily != "" && r.Match != nil {
+		r.mu.Unlock()
+		return NewError(ErrCodeDailyAlreadyPlayed)
 	}
-	if len(r.Players) < 2 || readyCount < len(r.Players)-1 {
+
+	// 檢查準備的玩家是否達到房間設定的比例
+	if !r.Solo && !r.ServerHosted && !r.readyCheckMetLocked() {
 		r.mu.Unlock()
-		return errors.New("玩家不足或部分玩家尚未準備")
+		return NewError(ErrCodeNotReady)
+	}
+
+	if r.Status != RoomStatusIntermission || r.Match == nil {
+		r.Match = NewMatch(r.Settings.Rounds)
 	}
+	r.Match.nextRound()
 
-	// 對每位玩家建立獨立的遊戲進度
+	// 對每位玩家建立獨立的遊戲進度，回合分數重新計算
+	// 共用題目模式下所有玩家拿到相同的題目序列
+	shared := NewGame(r.Settings)
+	if r.Seed != 0 {
+		shared = NewGameWithSeed(r.Settings, r.Seed)
+	}
+	now := time.Now()
 	for _, p := range r.Players {
-		p.Game = NewGame()
+		if r.Settings.SharedQuiz || r.Seed != 0 {
+			p.Game = shared.Clone()
+		} else {
+			p.Game = NewGame(r.Settings)
+		}
+		p.Game.SetLanguages(r.quizLanguages(p))
+		p.Game.Start(now)
+		p.Score = 0
+	}
+	r.firstCorrect = make(map[int]bool)
+	r.roundSeed = shared.Seed
+	r.roundStartedAt = now
+
+	// 計時模式時間到時由伺服器結束本局
+	r.stopReadyTimerLocked()
+	r.stopTimerLocked()
+	if r.Settings.Mode == GameModeTimed {
+		r.timer = time.AfterFunc(time.Duration(r.Settings.TimeLimit)*time.Second, r.onTimeUp)
 	}
 
 	r.Status = RoomStatusPlaying
+	players := r.playerSnapshotLocked()
 	r.mu.Unlock() // 釋放鎖後再發送訊息
 
 	// 廣播每位玩家的初始遊戲狀態
-	for _, p := range r.Players {
-		state, err := p.Game.GetStatus()
-		if err != nil {
-			logger.Output.Error("取得 %s 遊戲狀態失敗: %v", p.Name, err)
-			continue
-		}
-		gameStatePayload := map[string]interface{}{
-			"name":         p.Name,
-			"quiz":         state.Quiz,
-			"displayColor": state.DisplayColor,
-			"progress":     state.Progress,
-			"wrongCount":   state.WrongCount,
-			"totalQuiz":    state.TotalQuiz,
-			"isFinished":   state.IsFinished,
-		}
-		gameStateMsg := Message{
-			Type:    "game_state",
-			Payload: gameStatePayload,
-		}
-		if err := p.Send(gameStateMsg); err != nil {
-			logger.Output.Error("廣播遊戲狀態給 %s 失敗: %v", p.Name, err)
-		}
+	for _, p := range players {
+		r.sendGameState(p)
 	}
 
 	// 廣播遊戲開始訊息給所有玩家
 	gameStartMsg := Message{
 		Type:    MsgTypeGameStart,
-		Payload: "遊戲開始",
+		Payload: NoticePayload("遊戲開始"),
 	}
 	r.Broadcast(gameStartMsg)
 
-	logger.Output.Info("房間 %s 遊戲開始, 總玩家數: %d", r.ID, len(r.Players)-1)
+	logger.Output.Info("房間 %s 第 %d/%d 回合開始, 總玩家數: %d", r.ID, r.Match.CurrentRound, r.Match.TotalRounds, len(r.Players)-1)
 	return nil
 }
 
+// ForceStart 由伺服器直接開始遊戲，不檢查玩家是否準備（至少需要一位玩家）
+func (r *Room) ForceStart() error {
+	r.mu.Lock()
+	count := 0
+	for _, p := range r.Players {
+		if p.isCompetitor() {
+			p.IsReady = true
+			count++
+		}
+	}
+	r.mu.Unlock()
+
+	if count < MinPlayers {
+		return NewError(ErrCodeNotEnoughPlayers)
+	}
+	return r.StartGame()
+}
+
 // 處理玩家提交的答案，並更新該玩家獨立的遊戲進度
 func (r *Room) HandleAnswer(playerID, answer string) error {
 	r.mu.Lock()
 	player, exists := r.Players[playerID]
 	if !exists {
 		r.mu.Unlock()
-		return errors.New(ErrCodePlayerNotFound)
+		return NewError(ErrCodePlayerNotFound)
+	}
+	if player.IsSpectator {
+		r.mu.Unlock()
+		return NewError(ErrCodeSpectator)
 	}
 	if player.Game == nil {
 		r.mu.Unlock()
-		return errors.New(ErrCodeGameNotStarted)
+		return NewError(ErrCodeGameNotStarted)
 	}
-	logger.Output.Info("玩家 %s 提交答案: %s", player.Name, answer)
+	logger.Output.Debug("玩家 %s 提交答案: %s", player.Name, answer)
 
 	// 利用玩家自身的 Game 處理答案
+	question := player.Game.Progress
 	correct, err := player.Game.Answer(answer)
-	// 更新玩家分數
-	player.UpdateScore(correct)
-	
-	r.mu.Unlock()
-
 	if err != nil {
+		r.mu.Unlock()
 		logger.Output.Error("處理玩家 %s 提交答案失敗: %v", player.Name, err)
 		return err
 	}
 
-	state, err := player.Game.GetStatus()
-	if err != nil {
-		logger.Output.Error("取得 %s 遊戲狀態失敗: %v", player.Name, err)
-	} else {
-		updatedGameStateMsg := Message{
-			Type: "game_state",
-			Payload: map[string]interface{}{
-				"name":         player.Name,
-				"quiz":         state.Quiz,
-				"displayColor": state.DisplayColor,
-				"progress":     state.Progress,
-				"wrongCount":   state.WrongCount,
-				"totalQuiz":    state.TotalQuiz,
-				"isFinished":   state.IsFinished,
-			},
-		}
-		if err := player.Send(updatedGameStateMsg); err != nil {
-			logger.Output.Error("更新遊戲狀態給 %s 失敗: %v", player.Name, err)
+	// 共用題目時，第一位答對該題的玩家可獲得首答加分
+	first := false
+	if correct && r.Settings.SharedQuiz && !r.firstCorrect[question] {
+		r.firstCorrect[question] = true
+		first = true
+	}
+
+	// 更新玩家分數
+	events := player.UpdateScore(r.Settings.Scoring, correct, first)
+
+	// 分數變化只發送給作答的玩家，其他玩家經由合併後的玩家列表得知分數
+	for _, event := range events {
+		if err := r.sendToPlayerLocked(player, Message{Type: MsgTypeScoreEvent, Payload: event}); err != nil {
+			logger.Output.Error("發送分數變化給 %s 失敗: %v", player.Name, err)
+			break
 		}
 	}
+	r.mu.Unlock()
+
+	r.sendGameState(player)
 
-	// 檢查遊戲是否結束
+	// 檢查遊戲是否結束，結束時一併廣播玩家列表
 	if r.gameFinish() {
-		logger.Output.Info("遊戲結束，廣播結束訊息")
-		r.Broadcast(Message{
-			Type: MsgTypeGameEnd,
-			Payload: "遊戲結束",
-		})
+		r.endGame()
+		return nil
 	}
 
-	// 廣播更新後的玩家列表（狀態）
-	r.BroadcastPlayerList()
+	// 更新後的玩家列表合併後再廣播，避免每次作答都發送給所有玩家
+	r.markPlayerListDirty()
 	return nil
 }
 
-// 廣播消息給所有玩家
+// 發送玩家目前的遊戲狀態
+func (r *Room) sendGameState(p *Player) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state, err := p.Game.GetStatus()
+	if err != nil {
+		logger.Output.Error("取得 %s 遊戲狀態失敗: %v", p.Name, err)
+		return
+	}
+
+	gameStateMsg := Message{
+		Type: MsgTypeGameState,
+		Payload: GameStatePayload{
+			Name:       p.Name,
+			GameStatus: state,
+		},
+	}
+	if err := r.sendToPlayerLocked(p, gameStateMsg); err != nil {
+		logger.Output.Error("發送遊戲狀態給 %s 失敗: %v", p.Name, err)
+	}
+}
+
+// 結束本局：停止計時、結算回合並廣播結果，重複呼叫時只會結算一次
+func (r *Room) endGame() {
+	r.mu.Lock()
+	if r.Status != RoomStatusPlaying {
+		r.mu.Unlock()
+		return
+	}
+	r.stopTimerLocked()
+	now := time.Now()
+	for _, p := range r.Players {
+		p.Game.Finish(now)
+	}
+	match := r.closeRoundLocked()
+	r.mu.Unlock()
+
+	logger.Output.Info("遊戲結束，廣播結束訊息")
+	r.Broadcast(Message{
+		Type:    MsgTypeGameEnd,
+		Payload: NoticePayload("遊戲結束"),
+	})
+	r.broadcastRoundResult(match)
+	switch {
+	case r.Daily != "":
+		r.submitDailyResults()
+	case r.Challenge != nil:
+		r.reportChallengeResults()
+	case r.Solo:
+		r.reportPersonalBests()
+	}
+	r.BroadcastPlayerList()
+	r.scheduleAutoHostNext()
+}
+
+// 計時模式時間到：結束本局並將最終狀態發送給每位玩家
+func (r *Room) onTimeUp() {
+	r.mu.Lock()
+	playing := r.Status == RoomStatusPlaying
+	r.mu.Unlock()
+	if !playing {
+		return
+	}
+
+	logger.Output.Info("房間 %s 計時結束", r.ID)
+	r.endGame()
+	for _, p := range r.playerSnapshot() {
+		r.sendGameState(p)
+	}
+}
+
+// 停止計時器，呼叫時需持有 r.mu
+func (r *Room) stopTimerLocked() {
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+}
+
+// Close 釋放房間資源（例如所有玩家離開時）
+func (r *Room) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stopTimerLocked()
+	r.stopReadyTimerLocked()
+	r.stopAutoHostTimerLocked()
+	r.stopBroadcastTimerLocked()
+}
+
+// 取得目前玩家的副本，避免在發送訊息時持有鎖
+func (r *Room) playerSnapshot() []*Player {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.playerSnapshotLocked()
+}
+
+// 取得目前玩家的副本，呼叫時需持有 r.mu
+func (r *Room) playerSnapshotLocked() []*Player {
+	players := make([]*Player, 0, len(r.Players))
+	for _, p := range r.Players {
+		players = append(players, p)
+	}
+	return players
+}
+
+// 廣播消息給所有玩家，消息會編上房間序號並保留在事件緩衝區供 sync 重送
 func (r *Room) Broadcast(msg Message) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	msg = r.recordEventLocked(msg)
+	encoded := make(map[string][]byte)
 	for _, player := range r.Players {
-		player.Send(msg)
+		player.sendEncoded(msg, encoded)
 	}
 }
 
-// 重置每位玩家的遊戲狀態，並發送重新開始的通知
+// 重置每位玩家的遊戲狀態與比賽進度，並發送重新開始的通知
 func (r *Room) GameReset() error {
 	r.mu.Lock()
+	if r.Daily != "" {
+		r.mu.Unlock()
+		return NewError(ErrCodeDailyAlreadyPlayed)
+	}
 	r.Status = RoomStatusWaiting
+	r.Match = nil
+	r.stopTimerLocked()
 	for _, p := range r.Players {
-		p.ResetGame() // 每位玩家自行重置遊戲狀態
+		p.ResetGame(r.Settings) // 每位玩家自行重置遊戲狀態
 	}
 	r.mu.Unlock()
 
 	gameResetMsg := Message{
 		Type:    MsgTypeGameReset,
-		Payload: "遊戲重新開始",
+		Payload: NoticePayload("遊戲重新開始"),
 	}
 	r.Broadcast(gameResetMsg)
 
@@ -245,7 +455,7 @@ func (r *Room) GameReset() error {
 // 檢查遊戲是否結束
 func (r *Room) gameFinish() bool {
 	for _, p := range r.Players {
-		if !p.IsHost && !p.Game.IsFinished {
+		if p.isCompetitor() && !p.Game.IsFinished {
 			return false
 		}
 	}
@@ -0,0 +1,165 @@
+package game
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// 自訂 JSON Schema 的型別（例如解碼方式特殊的負載）
+type schemaProvider interface {
+	JSONSchema() map[string]interface{}
+}
+
+var (
+	timeType           = reflect.TypeOf(time.Time{})
+	rawMessageType     = reflect.TypeOf(json.RawMessage{})
+	schemaProviderType = reflect.TypeOf((*schemaProvider)(nil)).Elem()
+)
+
+// ProtocolSchema 產生描述所有 WebSocket 消息的 JSON Schema 文件（draft-07），供前端產生型別與驗證消息
+func ProtocolSchema() map[string]interface{} {
+	definitions := make(map[string]interface{})
+	refs := map[string][]interface{}{
+		DirectionClient: {},
+		DirectionServer: {},
+	}
+
+	for _, spec := range messageSpecs {
+		name := spec.Direction + "." + spec.Type
+		properties := map[string]interface{}{
+			"id":   map[string]interface{}{"type": "string", "maxLength": MaxRequestIDLength},
+			"type": map[string]interface{}{"const": spec.Type},
+		}
+		if spec.Direction == DirectionServer {
+			properties["seq"] = map[string]interface{}{"type": "integer", "minimum": 1}
+			properties["pseq"] = map[string]interface{}{"type": "integer", "minimum": 1}
+		}
+		required := []string{"type"}
+		if spec.Payload != nil {
+			properties["payload"] = typeSchema(reflect.TypeOf(spec.Payload), map[reflect.Type]bool{})
+			required = append(required, "payload")
+		}
+		definitions[name] = map[string]interface{}{
+			"description":          spec.Description,
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		}
+		refs[spec.Direction] = append(refs[spec.Direction], map[string]interface{}{
+			"$ref": "#/definitions/" + name,
+		})
+	}
+
+	codes := ErrorCodes()
+
+	definitions["ClientMessage"] = map[string]interface{}{"oneOf": refs[DirectionClient]}
+	definitions["ServerMessage"] = map[string]interface{}{"oneOf": refs[DirectionServer]}
+	definitions["ErrorCode"] = map[string]interface{}{"type": "string", "enum": codes}
+
+	return map[string]interface{}{
+		"$schema":     "http://json-schema.org/draft-07/schema#",
+		"title":       "Color Game WebSocket protocol",
+		"definitions": definitions,
+		"oneOf": []interface{}{
+			map[string]interface{}{"$ref": "#/definitions/ClientMessage"},
+			map[string]interface{}{"$ref": "#/definitions/ServerMessage"},
+		},
+	}
+}
+
+// 由 Go 型別產生 JSON Schema，欄位名稱與是否必填依照 json 標籤
+func typeSchema(t reflect.Type, visiting map[reflect.Type]bool) map[string]interface{} {
+	if t.Implements(schemaProviderType) {
+		return reflect.Zero(t).Interface().(schemaProvider).JSONSchema()
+	}
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case rawMessageType:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), visiting)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem(), visiting)}
+	case reflect.Ptr:
+		return map[string]interface{}{"oneOf": []interface{}{typeSchema(t.Elem(), visiting), map[string]interface{}{"type": "null"}}}
+	case reflect.Struct:
+		if visiting[t] {
+			return map[string]interface{}{"type": "object"}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		properties := make(map[string]interface{})
+		required := make([]string, 0)
+		collectFields(t, visiting, properties, &required)
+		schema := map[string]interface{}{
+			"type":       "object",
+			"properties": properties,
+		}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+	return map[string]interface{}{}
+}
+
+// 收集結構的 JSON 欄位，嵌入的結構會展開到同一層
+func collectFields(t reflect.Type, visiting map[reflect.Type]bool, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		if field.Anonymous && tag == "" && field.Type.Kind() == reflect.Struct {
+			collectFields(field.Type, visiting, properties, required)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := field.Name
+		omitempty := false
+		if tag != "" {
+			parts := strings.Split(tag, ",")
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			for _, opt := range parts[1:] {
+				if opt == "omitempty" {
+					omitempty = true
+				}
+			}
+		}
+		properties[name] = typeSchema(field.Type, visiting)
+		if !omitempty {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// JSONSchema 部分設定：欄位與房間設定相同但皆為選填
+func (SettingsPatch) JSONSchema() map[string]interface{} {
+	schema := typeSchema(reflect.TypeOf(RoomSettings{}), map[reflect.Type]bool{})
+	delete(schema, "required")
+	schema["additionalProperties"] = false
+	return schema
+}
@@ -0,0 +1,92 @@
+package game
+
+// DefaultScoringRules 預設計分規則：答對 +10、答錯 -5
+func DefaultScoringRules() ScoringRules {
+	return ScoringRules{
+		CorrectPoints: 10,
+		WrongPenalty:  5,
+	}
+}
+
+// 驗證計分規則是否合法
+func (rules ScoringRules) Validate() error {
+	values := []int{
+		rules.CorrectPoints,
+		rules.WrongPenalty,
+		rules.StreakStep,
+		rules.ComboSize,
+		rules.ComboBonus,
+		rules.FirstAnswerBonus,
+		rules.PenaltyCap,
+	}
+	for _, v := range values {
+		if v < 0 || v > MaxScoreValue {
+			return NewError(ErrCodeInvalidSettings)
+		}
+	}
+	if rules.MaxMultiplier < 0 || rules.MaxMultiplier > MaxMultiplier {
+		return NewError(ErrCodeInvalidSettings)
+	}
+	return nil
+}
+
+// 依目前連續答對次數計算倍率
+func (rules ScoringRules) multiplier(streak int) int {
+	if rules.StreakStep <= 0 || streak <= 0 {
+		return 1
+	}
+	m := 1 + (streak-1)/rules.StreakStep
+	if rules.MaxMultiplier > 0 && m > rules.MaxMultiplier {
+		m = rules.MaxMultiplier
+	}
+	return m
+}
+
+// Evaluate 根據作答結果更新遊戲的連擊與扣分紀錄，並回傳本次的分數變化事件
+// first 表示在共用題目的房間中，該玩家是否為此題第一位答對者
+func (rules ScoringRules) Evaluate(g *Game, correct bool, first bool) []ScoreEvent {
+	if !correct {
+		g.Streak = 0
+		penalty := rules.WrongPenalty
+		reason := ScoreReasonWrong
+		if rules.PenaltyCap > 0 {
+			// 已達扣分上限時不再扣分，也不發送沒有分數變化的事件
+			if g.PenaltyTotal >= rules.PenaltyCap {
+				return nil
+			}
+			// 只有達到上限的那一次作答標示為已達上限
+			if g.PenaltyTotal+penalty >= rules.PenaltyCap {
+				penalty = rules.PenaltyCap - g.PenaltyTotal
+				reason = ScoreReasonPenaltyCapped
+			}
+		}
+		g.PenaltyTotal += penalty
+		return []ScoreEvent{{Delta: -penalty, Reason: reason, Multiplier: 1}}
+	}
+
+	g.Streak++
+	m := rules.multiplier(g.Streak)
+	events := []ScoreEvent{{
+		Delta:      rules.CorrectPoints * m,
+		Reason:     ScoreReasonCorrect,
+		Streak:     g.Streak,
+		Multiplier: m,
+	}}
+	if rules.ComboSize > 0 && rules.ComboBonus > 0 && g.Streak%rules.ComboSize == 0 {
+		events = append(events, ScoreEvent{
+			Delta:      rules.ComboBonus,
+			Reason:     ScoreReasonCombo,
+			Streak:     g.Streak,
+			Multiplier: m,
+		})
+	}
+	if first && rules.FirstAnswerBonus > 0 {
+		events = append(events, ScoreEvent{
+			Delta:      rules.FirstAnswerBonus,
+			Reason:     ScoreReasonFirstAnswer,
+			Streak:     g.Streak,
+			Multiplier: m,
+		})
+	}
+	return events
+}
@@ -0,0 +1,128 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScoringMultiplier(t *testing.T) {
+	rules := ScoringRules{StreakStep: 3, MaxMultiplier: 3}
+	tests := []struct {
+		streak int
+		want   int
+	}{
+		{streak: 0, want: 1},
+		{streak: 1, want: 1},
+		{streak: 3, want: 1},
+		{streak: 4, want: 2},
+		{streak: 7, want: 3},
+		{streak: 100, want: 3},
+	}
+	for _, tt := range tests {
+		if got := rules.multiplier(tt.streak); got != tt.want {
+			t.Errorf("multiplier(%d) = %d, want %d", tt.streak, got, tt.want)
+		}
+	}
+	if got := (ScoringRules{}).multiplier(10); got != 1 {
+		t.Errorf("multiplier without streak step = %d, want 1", got)
+	}
+}
+
+func TestScoringEvaluate(t *testing.T) {
+	rules := ScoringRules{CorrectPoints: 10, WrongPenalty: 5, StreakStep: 2, MaxMultiplier: 2, ComboSize: 3, ComboBonus: 7, FirstAnswerBonus: 4}
+	g := &Game{}
+
+	// 連續答對：第 3 題倍率 2 並觸發連擊加分，搶先答對再加分
+	rules.Evaluate(g, true, false)
+	rules.Evaluate(g, true, false)
+	events := rules.Evaluate(g, true, true)
+	want := []ScoreEvent{
+		{Delta: 20, Reason: ScoreReasonCorrect, Streak: 3, Multiplier: 2},
+		{Delta: 7, Reason: ScoreReasonCombo, Streak: 3, Multiplier: 2},
+		{Delta: 4, Reason: ScoreReasonFirstAnswer, Streak: 3, Multiplier: 2},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("events = %+v, want %+v", events, want)
+	}
+
+	// 答錯中斷連擊
+	rules.Evaluate(g, false, false)
+	if g.Streak != 0 {
+		t.Fatalf("streak = %d after a wrong answer, want 0", g.Streak)
+	}
+}
+
+func TestScoringPenaltyCap(t *testing.T) {
+	tests := []struct {
+		name string
+		cap  int
+		// 每次答錯預期的分數變化與原因，nil 表示不產生事件
+		want []*ScoreEvent
+	}{
+		{
+			name: "no cap",
+			want: []*ScoreEvent{
+				{Delta: -5, Reason: ScoreReasonWrong, Multiplier: 1},
+				{Delta: -5, Reason: ScoreReasonWrong, Multiplier: 1},
+				{Delta: -5, Reason: ScoreReasonWrong, Multiplier: 1},
+			},
+		},
+		{
+			name: "cap reached exactly",
+			cap:  10,
+			want: []*ScoreEvent{
+				{Delta: -5, Reason: ScoreReasonWrong, Multiplier: 1},
+				{Delta: -5, Reason: ScoreReasonPenaltyCapped, Multiplier: 1},
+				nil,
+			},
+		},
+		{
+			name: "last penalty trimmed to cap",
+			cap:  8,
+			want: []*ScoreEvent{
+				{Delta: -5, Reason: ScoreReasonWrong, Multiplier: 1},
+				{Delta: -3, Reason: ScoreReasonPenaltyCapped, Multiplier: 1},
+				nil,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := ScoringRules{CorrectPoints: 10, WrongPenalty: 5, PenaltyCap: tt.cap}
+			g := &Game{}
+			for i, want := range tt.want {
+				events := rules.Evaluate(g, false, false)
+				if want == nil {
+					if len(events) != 0 {
+						t.Fatalf("answer %d: events = %+v, want none after the cap", i+1, events)
+					}
+					continue
+				}
+				if len(events) != 1 || events[0] != *want {
+					t.Fatalf("answer %d: events = %+v, want %+v", i+1, events, *want)
+				}
+			}
+		})
+	}
+}
+
+func TestScoringRulesValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   ScoringRules
+		wantErr bool
+	}{
+		{name: "default", rules: DefaultScoringRules()},
+		{name: "negative penalty", rules: ScoringRules{CorrectPoints: 10, WrongPenalty: -1}, wantErr: true},
+		{name: "points too large", rules: ScoringRules{CorrectPoints: MaxScoreValue + 1}, wantErr: true},
+		{name: "multiplier too large", rules: ScoringRules{CorrectPoints: 10, MaxMultiplier: MaxMultiplier + 1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rules.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
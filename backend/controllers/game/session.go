@@ -0,0 +1,43 @@
+package game
+
+import (
+	"github.com/google/uuid"
+)
+
+// 加入房間時發給玩家的重新連線憑證，斷線後以相同名稱與憑證重新加入時沿用原本的玩家 ID，
+// 以玩家 ID 為鍵的資料（請求結果、個人遊戲消息、比賽累積分數）因此不會因重新連線而中斷
+type playerSession struct {
+	playerID string
+	name     string
+}
+
+// 為新加入的玩家發放重新連線憑證，已沿用憑證的玩家不重新發放，呼叫時需持有 r.mu
+func (r *Room) issueSessionLocked(p *Player) {
+	if p.session != "" {
+		return
+	}
+	if r.sessions == nil {
+		r.sessions = make(map[string]playerSession)
+	}
+	p.session = uuid.New().String()
+	r.sessions[p.session] = playerSession{playerID: p.ID, name: p.Name}
+}
+
+// Resume 以重新連線憑證恢復玩家原本的 ID，需在 AddPlayer 之前呼叫
+// 憑證不存在、名稱不符或原本的連線仍在房間內時不做任何事，玩家以新的身分加入
+func (r *Room) Resume(p *Player, token string) {
+	if token == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[token]
+	if !ok || session.name != p.Name {
+		return
+	}
+	if _, connected := r.Players[session.playerID]; connected {
+		return
+	}
+	p.ID = session.playerID
+	p.session = token
+}
@@ -0,0 +1,81 @@
+package game
+
+import (
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/rejxcy/logger"
+)
+
+// NewSoloRoom 創建單人練習用的臨時房間，不會存入公開的房間列表
+func NewSoloRoom(settings RoomSettings) *Room {
+	room := NewRoom("solo-" + uuid.New().String())
+	room.Solo = true
+	room.Settings = settings
+	return room
+}
+
+// SoloSettings 依練習參數（模式、長度、語言、調色盤）建立房間設定
+// length 在一般模式為題數，計時模式為秒數；空字串表示使用預設值
+func SoloSettings(mode, length, language, palette string) (RoomSettings, error) {
+	settings := DefaultRoomSettings()
+	if mode != "" {
+		settings.Mode = GameMode(mode)
+	}
+	if length != "" {
+		n, err := strconv.Atoi(length)
+		if err != nil {
+			return settings, NewError(ErrCodeInvalidSettings)
+		}
+		if settings.Mode == GameModeTimed {
+			settings.TimeLimit = n
+		} else {
+			settings.QuizCount = n
+		}
+	}
+	if language != "" {
+		settings.QuizLanguage = language
+	}
+	if palette != "" && palette != PalettePresetCustom {
+		settings.PalettePreset = palette
+		if err := settings.resolvePalette(); err != nil {
+			return settings, err
+		}
+	}
+	return settings, settings.Validate()
+}
+
+// 單人練習結束時，將成績與個人最佳紀錄比較並通知玩家
+func (r *Room) reportPersonalBests() {
+	r.mu.Lock()
+	settings := r.Settings
+	type result struct {
+		player *Player
+		record GameRecord
+	}
+	results := make([]result, 0, len(r.Players))
+	for _, p := range r.Players {
+		if !p.IsHost {
+			results = append(results, result{player: p, record: NewGameRecord(p)})
+		}
+	}
+	r.mu.Unlock()
+
+	for _, res := range results {
+		prev, isNewBest := GlobalRecordStore.Submit(recordKey(res.player.Name, settings), res.record)
+		msg := Message{
+			Type: MsgTypePersonalBest,
+			Payload: PersonalBestPayload{
+				Mode:      settings.Mode,
+				QuizCount: settings.QuizCount,
+				TimeLimit: settings.TimeLimit,
+				Current:   res.record,
+				Previous:  prev,
+				IsNewBest: isNewBest,
+			},
+		}
+		if err := res.player.Send(msg); err != nil {
+			logger.Output.Error("發送個人紀錄給 %s 失敗: %v", res.player.Name, err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestSoloSettings(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      string
+		length    string
+		wantMode  GameMode
+		wantQuiz  int
+		wantLimit int
+		wantErr   bool
+	}{
+		{name: "defaults", wantMode: GameModeClassic, wantQuiz: QuizCount, wantLimit: DefaultTimeLimit},
+		{name: "classic length is quiz count", mode: "classic", length: "25", wantMode: GameModeClassic, wantQuiz: 25, wantLimit: DefaultTimeLimit},
+		{name: "timed length is seconds", mode: "timed", length: "30", wantMode: GameModeTimed, wantQuiz: QuizCount, wantLimit: 30},
+		{name: "non-numeric length", length: "ten", wantErr: true},
+		{name: "time limit too short", mode: "timed", length: "1", wantErr: true},
+		{name: "unknown mode", mode: "endless", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings, err := SoloSettings(tt.mode, tt.length, "", "")
+			if tt.wantErr {
+				if !errors.Is(err, NewError(ErrCodeInvalidSettings)) {
+					t.Fatalf("err = %v, want %s", err, ErrCodeInvalidSettings)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if settings.Mode != tt.wantMode || settings.QuizCount != tt.wantQuiz || settings.TimeLimit != tt.wantLimit {
+				t.Fatalf("settings = %s/%d/%d, want %s/%d/%d", settings.Mode, settings.QuizCount, settings.TimeLimit, tt.wantMode, tt.wantQuiz, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestSoloPersonalBest(t *testing.T) {
+	settings, err := SoloSettings("classic", "3", "", "")
+	if err != nil {
+		t.Fatalf("settings: %v", err)
+	}
+	room := NewSoloRoom(settings)
+	player, conn := joinTestPlayer(t, room, "solo-personal-best")
+	if err := room.StartGame(); err != nil {
+		t.Fatalf("start game: %v", err)
+	}
+	for i := 0; i < settings.QuizCount; i++ {
+		deliver(t, room, player, `{"type":"answer","payload":"`+currentAnswer(room, player)+`"}`)
+	}
+
+	for _, msg := range drainMessages(t, conn) {
+		if msg.Type != MsgTypePersonalBest {
+			continue
+		}
+		var best PersonalBestPayload
+		if err := json.Unmarshal(msg.Payload, &best); err != nil {
+			t.Fatalf("decode personal best: %v", err)
+		}
+		if !best.IsNewBest || best.Previous != nil || best.QuizCount != 3 {
+			t.Fatalf("personal best = %+v, want first record for 3 quizzes", best)
+		}
+		return
+	}
+	t.Fatal("no personal_best after finishing the practice")
+}
+
+func TestTimedGameStatus(t *testing.T) {
+	settings, err := SoloSettings("timed", "30", "", "")
+	if err != nil {
+		t.Fatalf("settings: %v", err)
+	}
+	room := NewSoloRoom(settings)
+	defer room.Close()
+	_, conn := joinTestPlayer(t, room, "alice")
+	if err := room.StartGame(); err != nil {
+		t.Fatalf("start game: %v", err)
+	}
+
+	for _, msg := range drainMessages(t, conn) {
+		if msg.Type != MsgTypeGameState {
+			continue
+		}
+		var state GameStatePayload
+		if err := json.Unmarshal(msg.Payload, &state); err != nil {
+			t.Fatalf("decode game state: %v", err)
+		}
+		// 計時模式沒有固定題數，客戶端依模式與截止時間顯示進度
+		status := state.GameStatus
+		if status.Mode != GameModeTimed || status.TotalQuiz != 0 || status.EndsAt == 0 {
+			t.Fatalf("status = %+v, want timed without quiz total and with a deadline", status)
+		}
+		return
+	}
+	t.Fatal("no game_state after starting the practice")
+}
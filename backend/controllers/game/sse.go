@@ -0,0 +1,210 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rejxcy/logger"
+)
+
+// SSE 備援傳輸的參數：部分網路環境封鎖 WebSocket 時，客戶端以 SSE 接收房間消息、以 POST 發送指令
+const (
+	// 尚未被讀取循環處理的客戶端消息上限，超過時拒絕新的消息
+	sseInboxSize = 32
+	// 心跳註解的間隔，避免代理伺服器因閒置而切斷串流
+	sseHeartbeatInterval = 15 * time.Second
+)
+
+// SSE 連線，伺服器消息以 JSON 事件推送，客戶端消息由 POST 請求轉入讀取循環
+type sseConn struct {
+	w      gin.ResponseWriter
+	rc     *http.ResponseController
+	remote string
+	inbox  chan []byte
+	done   chan struct{}
+	// 串流不允許同時寫入，關閉後不再寫入
+	mu     sync.Mutex
+	closed bool
+}
+
+func newSSEConn(w gin.ResponseWriter, remote string) *sseConn {
+	return &sseConn{
+		w:      w,
+		rc:     http.NewResponseController(w),
+		remote: remote,
+		inbox:  make(chan []byte, sseInboxSize),
+		done:   make(chan struct{}),
+	}
+}
+
+// SSE 串流為文字，固定使用 JSON 編碼
+func (c *sseConn) Codec() Codec { return jsonCodec{} }
+
+func (c *sseConn) ReadMessage() ([]byte, error) {
+	select {
+	case data := <-c.inbox:
+		return data, nil
+	case <-c.done:
+		return nil, NewError(ErrCodeConnectionClosed)
+	}
+}
+
+func (c *sseConn) WriteMessage(data []byte) error {
+	return c.writeEvent("", data)
+}
+
+// 推送關閉事件告知關閉碼與原因後結束串流
+func (c *sseConn) CloseWithCode(code int, reason string) {
+	data, _ := json.Marshal(struct {
+		Code   int    `json:"code"`
+		Reason string `json:"reason"`
+	}{code, reason})
+	c.writeEvent("close", data)
+	c.Close()
+}
+
+func (c *sseConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.closed = true
+		close(c.done)
+	}
+	return nil
+}
+
+func (c *sseConn) RemoteAddr() string { return c.remote }
+
+// 寫入一則事件，event 為空時為預設的 message 事件；JSON 編碼的內容不含換行，可直接作為單行 data
+// 與 WebSocket 相同套用寫入逾時，逾時或失敗後關閉連線
+func (c *sseConn) writeEvent(event string, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return NewError(ErrCodeConnectionClosed)
+	}
+	c.rc.SetWriteDeadline(time.Now().Add(wsConfig.WriteTimeout))
+	if event != "" {
+		fmt.Fprintf(c.w, "event: %s\n", event)
+	}
+	_, err := fmt.Fprintf(c.w, "data: %s\n\n", data)
+	if err == nil {
+		err = c.rc.Flush()
+	}
+	if err != nil {
+		c.closed = true
+		close(c.done)
+		return err
+	}
+	return nil
+}
+
+// 定期發送心跳，客戶端斷開請求時關閉連線使讀取循環結束
+func (c *sseConn) keepAlive(ctx context.Context) {
+	ticker := time.NewTicker(sseHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			c.Close()
+			return
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.mu.Lock()
+			if !c.closed {
+				fmt.Fprint(c.w, ": ping\n\n")
+				c.w.Flush()
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+// 將 POST 收到的消息交給讀取循環，讀取循環來不及處理時拒絕
+func (c *sseConn) push(data []byte) error {
+	select {
+	case <-c.done:
+		return NewError(ErrCodeConnectionClosed)
+	default:
+	}
+	select {
+	case c.inbox <- data:
+		return nil
+	default:
+		return NewError(ErrCodeRateLimited)
+	}
+}
+
+// 處理 SSE 串流的建立：參數與房間邏輯同 WebSocket 連線，第一則 session 事件提供發送指令用的 token
+func (c *controller) HandleSSE(ctx *gin.Context) {
+	roomID := ctx.Query("room_id")
+	playerName := ctx.Query("player_name")
+	isHost := ctx.Query("is_host") == "true"
+
+	if roomID == "" || playerName == "" {
+		ctx.String(http.StatusBadRequest, "缺少必要參數")
+		return
+	}
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	// 避免反向代理緩衝串流
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+
+	conn := newSSEConn(ctx.Writer, ctx.Request.RemoteAddr)
+	token := uuid.New().String()
+	c.sseConns.Store(token, conn)
+	defer c.sseConns.Delete(token)
+
+	session, _ := json.Marshal(gin.H{"token": token})
+	if err := conn.writeEvent("session", session); err != nil {
+		return
+	}
+	go conn.keepAlive(ctx.Request.Context())
+	defer conn.Close()
+
+	logger.Output.Info("New SSE connection: room=%s, player=%s, host=%v", roomID, playerName, isHost)
+	c.joinRoom(conn, roomID, playerName, isHost, ctx.Query("session"))
+}
+
+// 接收 SSE 連線的客戶端消息，消息格式同 WebSocket；處理結果與回覆經由串流推送
+func (c *controller) HandleSSESend(ctx *gin.Context) {
+	value, ok := c.sseConns.Load(ctx.Param("token"))
+	if !ok {
+		ctx.String(http.StatusNotFound, ErrCodeConnectionClosed)
+		return
+	}
+	conn := value.(*sseConn)
+
+	data, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, wsConfig.MaxMessageSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ctx.String(http.StatusRequestEntityTooLarge, ErrCodeInvalidMessage)
+			return
+		}
+		ctx.String(http.StatusBadRequest, ErrCodeInvalidMessage)
+		return
+	}
+
+	if err := conn.push(data); err != nil {
+		status := http.StatusGone
+		if errors.Is(err, NewError(ErrCodeRateLimited)) {
+			status = http.StatusTooManyRequests
+		}
+		ctx.String(status, err.Error())
+		return
+	}
+	ctx.Status(http.StatusAccepted)
+}
@@ -0,0 +1,173 @@
+package game
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rejxcy/colorgame/backend/controllers"
+)
+
+// 測試用的 SSE 事件
+type sseEvent struct {
+	Event string
+	Data  string
+}
+
+// 讀取下一則 SSE 事件，略過心跳註解
+func readSSEEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+	var ev sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			if ev.Data != "" {
+				return ev
+			}
+		case strings.HasPrefix(line, "event: "):
+			ev.Event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			ev.Data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+// 啟動只有 SSE 路由的測試伺服器
+func newSSETestServer(t *testing.T) (*controller, *httptest.Server) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	c := New(controllers.NewContext())
+	engine := gin.New()
+	engine.GET("/sse", c.HandleSSE)
+	engine.POST("/sse/:token", c.HandleSSESend)
+	server := httptest.NewServer(engine)
+	t.Cleanup(server.Close)
+	return c, server
+}
+
+func TestSSETransport(t *testing.T) {
+	c, server := newSSETestServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/sse?room_id=sse&player_name=alice&is_host=true", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type = %q, want text/event-stream", ct)
+	}
+	stream := bufio.NewReader(resp.Body)
+
+	// 第一則事件提供發送指令用的 token
+	first := readSSEEvent(t, stream)
+	var session struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal([]byte(first.Data), &session); first.Event != "session" || err != nil || session.Token == "" {
+		t.Fatalf("first event = %+v, want a session token", first)
+	}
+
+	// POST 的指令與 WebSocket 消息走相同的處理流程，回覆經由串流推送
+	send := func(body string) int {
+		t.Helper()
+		resp, err := http.Post(server.URL+"/sse/"+session.Token, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("send: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if status := send(`{"id":"s1","type":"update_settings","payload":{"quizCount":5}}`); status != http.StatusAccepted {
+		t.Fatalf("send status = %d, want %d", status, http.StatusAccepted)
+	}
+	for {
+		ev := readSSEEvent(t, stream)
+		var msg receivedMessage
+		if err := json.Unmarshal([]byte(ev.Data), &msg); err != nil {
+			t.Fatalf("decode event %+v: %v", ev, err)
+		}
+		if msg.ID == "s1" {
+			if msg.Type != MsgTypeAck {
+				t.Fatalf("reply = %s %s, want ack", msg.Type, msg.Payload)
+			}
+			break
+		}
+	}
+	if status := send(strings.Repeat("x", int(wsConfig.MaxMessageSize)+1)); status != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized send status = %d, want %d", status, http.StatusRequestEntityTooLarge)
+	}
+
+	// 客戶端斷開串流後 token 失效，房間隨房主離開而移除
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for send(`{"type":"sync","payload":{"since":0}}`) != http.StatusNotFound {
+		if time.Now().After(deadline) {
+			t.Fatal("token still accepted after the stream closed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	for c.getRoom("sse") != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("room still exists after the only player disconnected")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSSEConnPush(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	conn := newSSEConn(ctx.Writer, "test")
+
+	// 讀取循環來不及處理時拒絕新的消息
+	for i := 0; i < sseInboxSize; i++ {
+		if err := conn.push([]byte(`{}`)); err != nil {
+			t.Fatalf("push %d: %v", i, err)
+		}
+	}
+	if err := conn.push([]byte(`{}`)); !errors.Is(err, NewError(ErrCodeRateLimited)) {
+		t.Fatalf("push to a full inbox: err = %v, want %s", err, ErrCodeRateLimited)
+	}
+
+	conn.Close()
+	if err := conn.push([]byte(`{}`)); !errors.Is(err, NewError(ErrCodeConnectionClosed)) {
+		t.Fatalf("push after close: err = %v, want %s", err, ErrCodeConnectionClosed)
+	}
+	if err := conn.WriteMessage([]byte(`{}`)); !errors.Is(err, NewError(ErrCodeConnectionClosed)) {
+		t.Fatalf("write after close: err = %v, want %s", err, ErrCodeConnectionClosed)
+	}
+}
+
+func TestSSEConnCloseWithCode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	conn := newSSEConn(ctx.Writer, "test")
+
+	if err := conn.WriteMessage([]byte(`{"type":"welcome"}`)); err != nil {
+		t.Fatalf("write message: %v", err)
+	}
+	conn.CloseWithCode(4000, "kicked")
+	want := "data: {\"type\":\"welcome\"}\n\nevent: close\ndata: {\"code\":4000,\"reason\":\"kicked\"}\n\n"
+	if got := recorder.Body.String(); got != want {
+		t.Fatalf("stream = %q, want %q", got, want)
+	}
+	if _, err := conn.ReadMessage(); !errors.Is(err, NewError(ErrCodeConnectionClosed)) {
+		t.Fatalf("read after close: err = %v, want %s", err, ErrCodeConnectionClosed)
+	}
+}
@@ -0,0 +1,163 @@
+package game
+
+import (
+	"time"
+
+	"github.com/rejxcy/logger"
+)
+
+// 每個房間保留的廣播事件數量，客戶端落後超過此數量時改為發送完整快照
+const eventBufferSize = 128
+
+// SyncPayload 客戶端要求補齊錯過的事件；帶 since 時重送該序號之後的房間事件，帶 playerSince 時一併重送自己的遊戲消息
+// 未帶 since（或事件已不在緩衝區）時發送完整快照
+type SyncPayload struct {
+	Since       *uint64 `json:"since,omitempty"`
+	PlayerSince *uint64 `json:"playerSince,omitempty"`
+}
+
+// RoomSnapshot 房間目前的完整狀態，seq 為快照當下最後一個房間事件的序號，playerSeq 為請求者最後一則遊戲消息的序號
+type RoomSnapshot struct {
+	Seq       uint64            `json:"seq"`
+	PlayerSeq uint64            `json:"playerSeq"`
+	RoomID    string            `json:"roomId"`
+	Status    RoomStatus        `json:"status"`
+	Settings  RoomSettings      `json:"settings"`
+	Players   []PlayerListEntry `json:"players"`
+	Match     *Match            `json:"match,omitempty"`
+	Ready     *ReadyStatus      `json:"ready,omitempty"`
+	AutoHost  *AutoHostStatus   `json:"autoHost,omitempty"`
+	// 請求者自己的遊戲狀態，遊戲未開始或請求者不參與作答時省略
+	Game *GameStatePayload `json:"game,omitempty"`
+}
+
+// 依序編號的事件緩衝區，保留最近 eventBufferSize 個事件供重送
+type eventLog struct {
+	seq    uint64
+	events []loggedEvent
+}
+
+type loggedEvent struct {
+	seq uint64
+	msg Message
+}
+
+// 為事件編上下一個序號（由 stamp 寫入消息）並放入緩衝區
+func (l *eventLog) record(msg Message, stamp func(*Message, uint64)) Message {
+	l.seq++
+	stamp(&msg, l.seq)
+	event := loggedEvent{seq: l.seq, msg: msg}
+	if len(l.events) < eventBufferSize {
+		l.events = append(l.events, event)
+	} else {
+		copy(l.events, l.events[1:])
+		l.events[len(l.events)-1] = event
+	}
+	return msg
+}
+
+// 序號 since 之後的事件，緩衝區已不包含全部錯過的事件時返回 false
+func (l *eventLog) since(since uint64) ([]Message, bool) {
+	if since > l.seq {
+		return nil, false
+	}
+	if since == l.seq {
+		return nil, true
+	}
+	if len(l.events) == 0 || l.events[0].seq > since+1 {
+		return nil, false
+	}
+	start := len(l.events) - int(l.seq-since)
+	msgs := make([]Message, 0, len(l.events)-start)
+	for _, event := range l.events[start:] {
+		msgs = append(msgs, event.msg)
+	}
+	return msgs, true
+}
+
+// 為廣播事件編上房間序號並放入緩衝區，呼叫時需持有 r.mu
+func (r *Room) recordEventLocked(msg Message) Message {
+	return r.events.record(msg, func(m *Message, seq uint64) { m.Seq = seq })
+}
+
+// 序號 since 之後的房間事件，緩衝區已不包含全部錯過的事件時返回 false，呼叫時需持有 r.mu
+func (r *Room) eventsSinceLocked(since uint64) ([]Message, bool) {
+	return r.events.since(since)
+}
+
+// 玩家的遊戲消息緩衝區，以玩家 ID 為鍵，以重新連線憑證恢復身分後沿用；呼叫時需持有 r.mu
+func (r *Room) playerEventsLocked(playerID string) *eventLog {
+	if r.playerEvents == nil {
+		r.playerEvents = make(map[string]*eventLog)
+	}
+	log, ok := r.playerEvents[playerID]
+	if !ok {
+		log = &eventLog{}
+		r.playerEvents[playerID] = log
+	}
+	return log
+}
+
+// 發送只給單一玩家的遊戲消息，消息會編上該玩家的序號並保留在緩衝區供 sync 重送，呼叫時需持有 r.mu
+func (r *Room) sendToPlayerLocked(p *Player, msg Message) error {
+	msg = r.playerEventsLocked(p.ID).record(msg, func(m *Message, seq uint64) { m.PlayerSeq = seq })
+	return p.Send(msg)
+}
+
+// 房間目前的完整狀態，呼叫時需持有 r.mu
+func (r *Room) snapshotLocked(p *Player) RoomSnapshot {
+	snapshot := RoomSnapshot{
+		Seq:       r.events.seq,
+		PlayerSeq: r.playerEventsLocked(p.ID).seq,
+		RoomID:    r.ID,
+		Status:    r.Status,
+		Settings:  r.Settings,
+		Players:   r.playerListLocked(),
+	}
+	if r.Match != nil {
+		match := *r.Match
+		snapshot.Match = &match
+	}
+	if r.Status == RoomStatusWaiting && !r.Solo && !r.ServerHosted {
+		ready := r.readyStatusLocked(time.Now())
+		snapshot.Ready = &ready
+	}
+	if r.AutoHost != nil {
+		status, _ := r.autoHostStatusLocked()
+		snapshot.AutoHost = &status
+	}
+	if r.Status != RoomStatusWaiting && p.isCompetitor() {
+		if state, err := p.Game.GetStatus(); err == nil {
+			snapshot.Game = &GameStatePayload{Name: p.Name, GameStatus: state}
+		}
+	}
+	return snapshot
+}
+
+// Sync 補齊玩家錯過的廣播事件與自己的遊戲消息，任一無法重送時改發完整快照
+// 重送期間持有 r.mu，確保新的消息不會插在重送的消息之前
+func (r *Room) Sync(p *Player, payload SyncPayload) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if payload.Since != nil {
+		events, ok := r.eventsSinceLocked(*payload.Since)
+		if ok && payload.PlayerSince != nil {
+			var personal []Message
+			personal, ok = r.playerEventsLocked(p.ID).since(*payload.PlayerSince)
+			events = append(events, personal...)
+		}
+		if ok {
+			for _, msg := range events {
+				if err := p.Send(msg); err != nil {
+					logger.Output.Error("重送事件給 %s 失敗: %v", p.Name, err)
+					return
+				}
+			}
+			return
+		}
+	}
+	if err := p.Send(Message{Type: MsgTypeSnapshot, Payload: r.snapshotLocked(p)}); err != nil {
+		logger.Output.Error("發送房間快照給 %s 失敗: %v", p.Name, err)
+	}
+}
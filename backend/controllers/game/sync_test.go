@@ -0,0 +1,127 @@
+package game
+
+import (
+	"strconv"
+	"testing"
+)
+
+// 記錄 n 個廣播事件的房間
+func roomWithEvents(n int) *Room {
+	r := NewRoom("sync")
+	for i := 0; i < n; i++ {
+		r.recordEventLocked(Message{Type: MsgTypePlayerList})
+	}
+	return r
+}
+
+func TestEventsSinceLocked(t *testing.T) {
+	tests := []struct {
+		name     string
+		recorded int
+		since    uint64
+		wantOK   bool
+		// 預期重送的第一個序號與事件數量
+		wantFirst uint64
+		wantCount int
+	}{
+		{name: "no events", recorded: 0, since: 0, wantOK: true},
+		{name: "up to date", recorded: 5, since: 5, wantOK: true},
+		{name: "missed some", recorded: 5, since: 2, wantOK: true, wantFirst: 3, wantCount: 3},
+		{name: "missed all", recorded: 5, since: 0, wantOK: true, wantFirst: 1, wantCount: 5},
+		{name: "ahead of room", recorded: 5, since: 6, wantOK: false},
+		{name: "full buffer", recorded: eventBufferSize, since: 0, wantOK: true, wantFirst: 1, wantCount: eventBufferSize},
+		{name: "oldest still buffered", recorded: eventBufferSize + 10, since: 10, wantOK: true, wantFirst: 11, wantCount: eventBufferSize},
+		{name: "evicted", recorded: eventBufferSize + 10, since: 9, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := roomWithEvents(tt.recorded)
+			events, ok := r.eventsSinceLocked(tt.since)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if len(events) != tt.wantCount {
+				t.Fatalf("got %d events, want %d", len(events), tt.wantCount)
+			}
+			for i, msg := range events {
+				if want := tt.wantFirst + uint64(i); msg.Seq != want {
+					t.Fatalf("events[%d].Seq = %d, want %d", i, msg.Seq, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPlayerEventsAreSequencedPerPlayer(t *testing.T) {
+	r := NewRoom("sync")
+	// 同名的兩位玩家各自擁有獨立的序號
+	alice := NewPlayer(NewMemoryConn("alice"), "alice", false)
+	other := NewPlayer(NewMemoryConn("alice"), "alice", false)
+
+	r.mu.Lock()
+	r.sendToPlayerLocked(alice, Message{Type: MsgTypeGameState})
+	r.sendToPlayerLocked(other, Message{Type: MsgTypeGameState})
+	r.sendToPlayerLocked(alice, Message{Type: MsgTypeScoreEvent})
+	aliceEvents, aliceOK := r.playerEventsLocked(alice.ID).since(1)
+	otherEvents, otherOK := r.playerEventsLocked(other.ID).since(0)
+	otherSnapshot := r.snapshotLocked(other)
+	r.mu.Unlock()
+
+	if !aliceOK || len(aliceEvents) != 1 || aliceEvents[0].PlayerSeq != 2 || aliceEvents[0].Type != MsgTypeScoreEvent {
+		t.Fatalf("alice events = %+v (ok %v), want only score_event with pseq 2", aliceEvents, aliceOK)
+	}
+	if !otherOK || len(otherEvents) != 1 || otherEvents[0].PlayerSeq != 1 {
+		t.Fatalf("other events = %+v (ok %v), want one event with pseq 1", otherEvents, otherOK)
+	}
+	if otherSnapshot.PlayerSeq != 1 {
+		t.Fatalf("other snapshot playerSeq = %d, want 1", otherSnapshot.PlayerSeq)
+	}
+	if r.events.seq != 0 {
+		t.Fatalf("room seq = %d, per-player messages must not use room sequence", r.events.seq)
+	}
+}
+
+func TestSyncReplaysPlayerMessages(t *testing.T) {
+	room := NewRoom("sync")
+	room.Solo = true
+	player, conn := joinTestPlayer(t, room, "alice")
+	deliver(t, room, player, testHello)
+	if err := room.StartGame(); err != nil {
+		t.Fatalf("start game: %v", err)
+	}
+	deliver(t, room, player, `{"type":"answer","payload":"`+currentAnswer(room, player)+`"}`)
+
+	var lastSeq uint64
+	var personal []receivedMessage
+	for _, msg := range drainMessages(t, conn) {
+		if msg.Seq > lastSeq {
+			lastSeq = msg.Seq
+		}
+		if msg.PlayerSeq > 0 {
+			personal = append(personal, msg)
+		}
+	}
+	if len(personal) < 2 {
+		t.Fatalf("got %d per-player messages, want game state and score event", len(personal))
+	}
+
+	// 只收到第一則個人消息就斷線，sync 應重送之後的個人消息
+	since := strconv.FormatUint(lastSeq, 10)
+	deliver(t, room, player, `{"type":"sync","payload":{"since":`+since+`,"playerSince":1}}`)
+	replayed := drainMessages(t, conn)
+	if len(replayed) != len(personal)-1 {
+		t.Fatalf("replayed %d messages, want %d", len(replayed), len(personal)-1)
+	}
+	for i, msg := range replayed {
+		if want := personal[i+1]; msg.PlayerSeq != want.PlayerSeq || msg.Type != want.Type {
+			t.Fatalf("replayed[%d] = %s pseq %d, want %s pseq %d", i, msg.Type, msg.PlayerSeq, want.Type, want.PlayerSeq)
+		}
+	}
+
+	// 已清出緩衝區的序號改發完整快照
+	deliver(t, room, player, `{"type":"sync","payload":{"since":`+since+`,"playerSince":99}}`)
+	if msgs := drainMessages(t, conn); len(msgs) != 1 || msgs[0].Type != MsgTypeSnapshot {
+		t.Fatalf("sync ahead of stream: got %+v, want snapshot", msgs)
+	}
+}
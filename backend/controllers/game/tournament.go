@@ -0,0 +1,460 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rejxcy/logger"
+)
+
+// TournamentStatus 定義錦標賽狀態
+type TournamentStatus string
+
+// 錦標賽狀態常數
+const (
+	TournamentStatusRegistering TournamentStatus = "registering"
+	TournamentStatusRunning     TournamentStatus = "running"
+	TournamentStatusFinished    TournamentStatus = "finished"
+)
+
+// 分組賽狀態常數
+const (
+	HeatStatusPending  = "pending"
+	HeatStatusPlaying  = "playing"
+	HeatStatusFinished = "finished"
+)
+
+// 錦標賽相關常數
+const (
+	TournamentCodeLength     = 6
+	MaxTournamentEntrants    = 256
+	MinTournamentHeatSize    = 2
+	DefaultTournamentAdvance = 2
+)
+
+// 建立錦標賽的參數
+type TournamentConfig struct {
+	Name     string        `json:"name"`
+	HeatSize int           `json:"heatSize"`
+	Advance  int           `json:"advance"`
+	Settings *RoomSettings `json:"settings"`
+}
+
+// 報名的玩家，Seed 為報名順序決定的種子序號
+type Registrant struct {
+	Name string `json:"name"`
+	Seed int    `json:"seed"`
+}
+
+// 分組賽中單一玩家的成績
+type HeatResult struct {
+	Name     string `json:"name"`
+	Score    int    `json:"score"`
+	Rank     int    `json:"rank"`
+	Advanced bool   `json:"advanced"`
+}
+
+// 分組賽（對應一個房間）
+type Heat struct {
+	Number  int          `json:"number"`
+	RoomID  string       `json:"roomId"`
+	Players []string     `json:"players"`
+	Status  string       `json:"status"`
+	Results []HeatResult `json:"results"`
+}
+
+// 錦標賽的一輪，由多場分組賽組成
+type TournamentRound struct {
+	Number int     `json:"number"`
+	Final  bool    `json:"final"`
+	Heats  []*Heat `json:"heats"`
+}
+
+// Tournament 管理報名、分組、晉級與對戰表狀態
+type Tournament struct {
+	ID          string             `json:"id"`
+	Name        string             `json:"name"`
+	Status      TournamentStatus   `json:"status"`
+	HeatSize    int                `json:"heatSize"`
+	Advance     int                `json:"advance"`
+	Settings    RoomSettings       `json:"settings"`
+	Registrants []Registrant       `json:"registrants"`
+	Rounds      []*TournamentRound `json:"rounds"`
+	Champion    string             `json:"champion"`
+	CreatedAt   time.Time          `json:"createdAt"`
+
+	// 建立與移除分組賽房間（由控制器注入房間儲存）
+	storeRoom  func(*Room)
+	removeRoom func(id string)
+	// 訂閱對戰表更新的連線
+	subscribers map[*tournamentSubscriber]bool
+	mu          sync.Mutex
+}
+
+// 訂閱錦標賽動態的連線
+type tournamentSubscriber struct {
+	conn Conn
+	mu   sync.Mutex
+}
+
+// NewTournament 依設定建立錦標賽
+func NewTournament(config TournamentConfig, storeRoom func(*Room), removeRoom func(id string)) (*Tournament, error) {
+	settings := DefaultRoomSettings()
+	if config.Settings != nil {
+		settings = *config.Settings
+		if err := settings.resolvePalette(); err != nil {
+			return nil, err
+		}
+	}
+	if config.HeatSize == 0 {
+		config.HeatSize = MaxPlayers
+	}
+	if config.Advance == 0 {
+		config.Advance = DefaultTournamentAdvance
+	}
+	if config.Name == "" ||
+		config.HeatSize < MinTournamentHeatSize || config.HeatSize > MaxPlayers ||
+		config.Advance < 1 || config.Advance >= config.HeatSize {
+		return nil, NewError(ErrCodeInvalidSettings)
+	}
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+
+	id, err := newShortCode(TournamentCodeLength)
+	if err != nil {
+		return nil, err
+	}
+	return &Tournament{
+		ID:          id,
+		Name:        config.Name,
+		Status:      TournamentStatusRegistering,
+		HeatSize:    config.HeatSize,
+		Advance:     config.Advance,
+		Settings:    settings,
+		Registrants: make([]Registrant, 0),
+		Rounds:      make([]*TournamentRound, 0),
+		CreatedAt:   time.Now(),
+		storeRoom:   storeRoom,
+		removeRoom:  removeRoom,
+		subscribers: make(map[*tournamentSubscriber]bool),
+	}, nil
+}
+
+// Register 報名參加錦標賽，名稱不可重複
+func (t *Tournament) Register(name string) (Registrant, error) {
+	t.mu.Lock()
+	if t.Status != TournamentStatusRegistering {
+		t.mu.Unlock()
+		return Registrant{}, NewError(ErrCodeTournamentStarted)
+	}
+	if name == "" || len(t.Registrants) >= MaxTournamentEntrants {
+		t.mu.Unlock()
+		return Registrant{}, NewError(ErrCodeInvalidSettings)
+	}
+	for _, r := range t.Registrants {
+		if r.Name == name {
+			t.mu.Unlock()
+			return Registrant{}, NewError(ErrCodeAlreadyRegistered)
+		}
+	}
+	registrant := Registrant{Name: name, Seed: len(t.Registrants) + 1}
+	t.Registrants = append(t.Registrants, registrant)
+	t.mu.Unlock()
+
+	t.broadcast()
+	return registrant, nil
+}
+
+// Start 結束報名並將報名者分入第一輪的分組賽
+func (t *Tournament) Start() error {
+	t.mu.Lock()
+	if t.Status != TournamentStatusRegistering {
+		t.mu.Unlock()
+		return NewError(ErrCodeTournamentStarted)
+	}
+	if len(t.Registrants) < MinTournamentHeatSize {
+		t.mu.Unlock()
+		return NewError(ErrCodeNotEnoughPlayers)
+	}
+
+	names := make([]string, len(t.Registrants))
+	for i, r := range t.Registrants {
+		names[i] = r.Name
+	}
+	t.Status = TournamentStatusRunning
+	t.startRoundLocked(names)
+	t.mu.Unlock()
+
+	logger.Output.Info("錦標賽 %s 開始，共 %d 名參賽者", t.ID, len(names))
+	t.broadcast()
+	return nil
+}
+
+// StartHeat 由主辦方開始指定的分組賽（不需等待所有玩家準備）
+// 多回合的分組賽在回合間休息時再次呼叫即開始下一回合；人數不足無法開始時以棄權結束該組
+func (t *Tournament) StartHeat(roundNumber, heatNumber int, room *Room) error {
+	t.mu.Lock()
+	heat := t.heatLocked(roundNumber, heatNumber)
+	if heat == nil || room == nil || heat.RoomID != room.ID {
+		t.mu.Unlock()
+		return NewError(ErrCodeHeatNotFound)
+	}
+	if heat.Status == HeatStatusFinished {
+		t.mu.Unlock()
+		return NewError(ErrCodeGameFinished)
+	}
+	t.mu.Unlock()
+
+	if err := room.ForceStart(); err != nil {
+		if !errors.Is(err, NewError(ErrCodeNotEnoughPlayers)) {
+			return err
+		}
+		t.forfeitHeat(roundNumber, heatNumber, room)
+		return nil
+	}
+
+	t.mu.Lock()
+	heat.Status = HeatStatusPlaying
+	t.mu.Unlock()
+	t.broadcast()
+	return nil
+}
+
+// 人數不足無法開賽的分組賽視為棄權：已進行過的回合依目前成績結算，
+// 尚未開賽時已加入的玩家同列第一直接晉級，沒有玩家時以空成績結束
+func (t *Tournament) forfeitHeat(roundNumber, heatNumber int, room *Room) {
+	room.mu.Lock()
+	standings := make([]MatchStanding, 0)
+	if room.Match != nil && len(room.Match.TotalScores) > 0 {
+		standings = room.Match.Standings()
+	} else {
+		for _, p := range room.Players {
+			if p.isCompetitor() {
+				standings = append(standings, MatchStanding{ID: p.ID, Name: p.Name, Rank: 1})
+			}
+		}
+	}
+	room.mu.Unlock()
+
+	logger.Output.Info("錦標賽 %s 第 %d 輪第 %d 組人數不足，以棄權結束", t.ID, roundNumber, heatNumber)
+	t.finishHeat(roundNumber, heatNumber, room, standings)
+}
+
+// 取得指定輪次與組別的分組賽，呼叫時需持有 t.mu
+func (t *Tournament) heatLocked(roundNumber, heatNumber int) *Heat {
+	if roundNumber < 1 || roundNumber > len(t.Rounds) {
+		return nil
+	}
+	round := t.Rounds[roundNumber-1]
+	if heatNumber < 1 || heatNumber > len(round.Heats) {
+		return nil
+	}
+	return round.Heats[heatNumber-1]
+}
+
+// 以蛇形方式將玩家分入各組並建立房間，呼叫時需持有 t.mu
+// 例如 3 組時種子 1,2,3 分入第 1,2,3 組，種子 4,5,6 分入第 3,2,1 組
+func (t *Tournament) startRoundLocked(names []string) {
+	heatCount := (len(names) + t.HeatSize - 1) / t.HeatSize
+	round := &TournamentRound{
+		Number: len(t.Rounds) + 1,
+		Final:  heatCount == 1,
+		Heats:  make([]*Heat, heatCount),
+	}
+	for i := range round.Heats {
+		round.Heats[i] = &Heat{
+			Number:  i + 1,
+			RoomID:  fmt.Sprintf("T%s-R%dH%d", t.ID, round.Number, i+1),
+			Players: make([]string, 0, t.HeatSize),
+			Status:  HeatStatusPending,
+		}
+	}
+	for i, name := range names {
+		idx := i % heatCount
+		if (i/heatCount)%2 == 1 {
+			idx = heatCount - 1 - idx
+		}
+		round.Heats[idx].Players = append(round.Heats[idx].Players, name)
+	}
+	t.Rounds = append(t.Rounds, round)
+
+	for _, heat := range round.Heats {
+		room := NewRoom(heat.RoomID)
+		room.Settings = t.Settings
+		room.ServerHosted = true
+		room.Persistent = true
+		room.AllowedNames = make(map[string]bool, len(heat.Players))
+		for _, name := range heat.Players {
+			room.AllowedNames[name] = true
+		}
+		roundNumber, heatNumber := round.Number, heat.Number
+		room.OnMatchEnd = func(standings []MatchStanding) {
+			t.finishHeat(roundNumber, heatNumber, room, standings)
+		}
+		t.storeRoom(room)
+	}
+}
+
+// 分組賽結束：記錄成績與晉級者並關閉房間，該輪全部結束時進入下一輪或產生冠軍
+func (t *Tournament) finishHeat(roundNumber, heatNumber int, room *Room, standings []MatchStanding) {
+	t.mu.Lock()
+	heat := t.heatLocked(roundNumber, heatNumber)
+	if heat == nil || heat.Status == HeatStatusFinished {
+		t.mu.Unlock()
+		return
+	}
+
+	// 每組至少淘汰一人，避免人數不足的分組賽讓錦標賽無法收斂
+	advance := t.Advance
+	if advance >= len(standings) {
+		advance = len(standings) - 1
+	}
+	if advance < 1 {
+		advance = len(standings)
+	}
+
+	round := t.Rounds[roundNumber-1]
+	heat.Status = HeatStatusFinished
+	heat.Results = make([]HeatResult, len(standings))
+	for i, s := range standings {
+		heat.Results[i] = HeatResult{
+			Name:     s.Name,
+			Score:    s.TotalScore,
+			Rank:     s.Rank,
+			Advanced: !round.Final && i < advance,
+		}
+	}
+	// 先停止房間的計時器再移除，避免已結束的分組賽仍觸發計時事件
+	room.Close()
+	t.removeRoom(heat.RoomID)
+	logger.Output.Info("錦標賽 %s 第 %d 輪第 %d 組結束", t.ID, roundNumber, heatNumber)
+
+	if roundNumber == len(t.Rounds) && t.roundFinishedLocked(round) {
+		t.advanceLocked(round)
+	}
+	t.mu.Unlock()
+
+	t.broadcast()
+}
+
+// 判斷一輪是否所有分組賽皆已結束，呼叫時需持有 t.mu
+func (t *Tournament) roundFinishedLocked(round *TournamentRound) bool {
+	for _, heat := range round.Heats {
+		if heat.Status != HeatStatusFinished {
+			return false
+		}
+	}
+	return true
+}
+
+// 依各組名次交錯排列晉級者並開始下一輪；決賽結束時產生冠軍，呼叫時需持有 t.mu
+func (t *Tournament) advanceLocked(round *TournamentRound) {
+	if round.Final {
+		if len(round.Heats[0].Results) > 0 {
+			t.Champion = round.Heats[0].Results[0].Name
+		}
+		t.Status = TournamentStatusFinished
+		logger.Output.Info("錦標賽 %s 結束，冠軍: %s", t.ID, t.Champion)
+		return
+	}
+
+	advancers := make([]string, 0, len(round.Heats)*t.Advance)
+	for place := 0; place < t.Advance; place++ {
+		for _, heat := range round.Heats {
+			if place < len(heat.Results) && heat.Results[place].Advanced {
+				advancers = append(advancers, heat.Results[place].Name)
+			}
+		}
+	}
+
+	switch len(advancers) {
+	case 0:
+		t.Status = TournamentStatusFinished
+	case 1:
+		t.Champion = advancers[0]
+		t.Status = TournamentStatusFinished
+	default:
+		t.startRoundLocked(advancers)
+	}
+}
+
+// Snapshot 取得目前對戰表的副本（用於 REST 回應與動態推送）
+func (t *Tournament) Snapshot() *Tournament {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	snapshot := &Tournament{
+		ID:          t.ID,
+		Name:        t.Name,
+		Status:      t.Status,
+		HeatSize:    t.HeatSize,
+		Advance:     t.Advance,
+		Settings:    t.Settings,
+		Registrants: append([]Registrant(nil), t.Registrants...),
+		Rounds:      make([]*TournamentRound, len(t.Rounds)),
+		Champion:    t.Champion,
+		CreatedAt:   t.CreatedAt,
+	}
+	for i, round := range t.Rounds {
+		copied := *round
+		copied.Heats = make([]*Heat, len(round.Heats))
+		for j, heat := range round.Heats {
+			h := *heat
+			h.Players = append([]string(nil), heat.Players...)
+			h.Results = append([]HeatResult(nil), heat.Results...)
+			copied.Heats[j] = &h
+		}
+		snapshot.Rounds[i] = &copied
+	}
+	return snapshot
+}
+
+// Subscribe 訂閱對戰表動態，訂閱時立即推送目前狀態
+func (t *Tournament) Subscribe(conn Conn) func() {
+	sub := &tournamentSubscriber{conn: conn}
+	t.mu.Lock()
+	t.subscribers[sub] = true
+	t.mu.Unlock()
+
+	snapshot := t.Snapshot()
+	sub.send(Message{Type: MsgTypeTournamentState, Payload: snapshot})
+
+	return func() {
+		t.mu.Lock()
+		delete(t.subscribers, sub)
+		t.mu.Unlock()
+	}
+}
+
+// 推送目前對戰表給所有訂閱者
+func (t *Tournament) broadcast() {
+	snapshot := t.Snapshot()
+	msg := Message{Type: MsgTypeTournamentState, Payload: snapshot}
+
+	t.mu.Lock()
+	subs := make([]*tournamentSubscriber, 0, len(t.subscribers))
+	for sub := range t.subscribers {
+		subs = append(subs, sub)
+	}
+	t.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.send(msg)
+	}
+}
+
+// 發送消息給訂閱者
+func (s *tournamentSubscriber) send(msg Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := s.conn.Codec().Encode(msg)
+	if err == nil {
+		err = s.conn.WriteMessage(data)
+	}
+	if err != nil {
+		logger.Output.Error("推送錦標賽動態失敗: %v", err)
+	}
+}
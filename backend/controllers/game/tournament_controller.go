@@ -0,0 +1,141 @@
+package game
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rejxcy/logger"
+)
+
+// 建立錦標賽
+func (c *controller) CreateTournament(ctx *gin.Context) {
+	var config TournamentConfig
+	if err := ctx.ShouldBindJSON(&config); err != nil {
+		ctx.String(http.StatusBadRequest, ErrCodeInvalidSettings)
+		return
+	}
+
+	tournament, err := NewTournament(config, c.storeRoom, c.removeRoom)
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.Base.Tournaments.Store(tournament.ID, tournament)
+	logger.Output.Info("Tournament %s (%s) created", tournament.ID, tournament.Name)
+
+	ctx.JSON(http.StatusCreated, tournament.Snapshot())
+}
+
+// 查詢錦標賽對戰表
+func (c *controller) GetTournament(ctx *gin.Context) {
+	tournament := c.getTournament(ctx.Param("id"))
+	if tournament == nil {
+		ctx.String(http.StatusNotFound, ErrCodeTournamentNotFound)
+		return
+	}
+	ctx.JSON(http.StatusOK, tournament.Snapshot())
+}
+
+// 報名錦標賽
+func (c *controller) RegisterTournament(ctx *gin.Context) {
+	tournament := c.getTournament(ctx.Param("id"))
+	if tournament == nil {
+		ctx.String(http.StatusNotFound, ErrCodeTournamentNotFound)
+		return
+	}
+
+	var body struct {
+		Name string `json:"name"`
+	}
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.String(http.StatusBadRequest, ErrCodeInvalidMessage)
+		return
+	}
+
+	registrant, err := tournament.Register(body.Name)
+	if err != nil {
+		ctx.String(http.StatusConflict, err.Error())
+		return
+	}
+	ctx.JSON(http.StatusCreated, registrant)
+}
+
+// 結束報名並開始第一輪
+func (c *controller) StartTournament(ctx *gin.Context) {
+	tournament := c.getTournament(ctx.Param("id"))
+	if tournament == nil {
+		ctx.String(http.StatusNotFound, ErrCodeTournamentNotFound)
+		return
+	}
+	if err := tournament.Start(); err != nil {
+		ctx.String(http.StatusConflict, err.Error())
+		return
+	}
+	ctx.JSON(http.StatusOK, tournament.Snapshot())
+}
+
+// 開始指定的分組賽
+func (c *controller) StartTournamentHeat(ctx *gin.Context) {
+	tournament := c.getTournament(ctx.Param("id"))
+	if tournament == nil {
+		ctx.String(http.StatusNotFound, ErrCodeTournamentNotFound)
+		return
+	}
+
+	roundNumber, err1 := strconv.Atoi(ctx.Param("round"))
+	heatNumber, err2 := strconv.Atoi(ctx.Param("heat"))
+	if err1 != nil || err2 != nil {
+		ctx.String(http.StatusBadRequest, ErrCodeHeatNotFound)
+		return
+	}
+
+	var room *Room
+	snapshot := tournament.Snapshot()
+	if roundNumber >= 1 && roundNumber <= len(snapshot.Rounds) {
+		heats := snapshot.Rounds[roundNumber-1].Heats
+		if heatNumber >= 1 && heatNumber <= len(heats) {
+			room = c.getRoom(heats[heatNumber-1].RoomID)
+		}
+	}
+
+	if err := tournament.StartHeat(roundNumber, heatNumber, room); err != nil {
+		ctx.String(http.StatusConflict, err.Error())
+		return
+	}
+	ctx.JSON(http.StatusOK, tournament.Snapshot())
+}
+
+// 錦標賽動態的 WebSocket 連線（供主辦方大螢幕使用），每次對戰表變動時推送最新狀態
+func (c *controller) HandleTournamentFeed(ctx *gin.Context) {
+	tournament := c.getTournament(ctx.Param("id"))
+	if tournament == nil {
+		ctx.String(http.StatusNotFound, ErrCodeTournamentNotFound)
+		return
+	}
+
+	conn, err := upgradeConn(ctx.Writer, ctx.Request)
+	if err != nil {
+		logger.Output.Error("WebSocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	unsubscribe := tournament.Subscribe(conn)
+	defer unsubscribe()
+
+	// 動態推送為單向，僅讀取以偵測連線關閉
+	for {
+		if _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// 從 Context 中獲取錦標賽
+func (c *controller) getTournament(id string) *Tournament {
+	if t, ok := c.Base.Tournaments.Load(id); ok {
+		return t.(*Tournament)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package game
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// 建立已報名指定人數的錦標賽，分組賽房間記錄在 rooms 中
+func newTestTournament(t *testing.T, config TournamentConfig, entrants int) (*Tournament, map[string]*Room) {
+	t.Helper()
+	rooms := make(map[string]*Room)
+	tournament, err := NewTournament(config,
+		func(room *Room) { rooms[room.ID] = room },
+		func(id string) { delete(rooms, id) })
+	if err != nil {
+		t.Fatalf("new tournament: %v", err)
+	}
+	for i := 1; i <= entrants; i++ {
+		if _, err := tournament.Register("p" + strconv.Itoa(i)); err != nil {
+			t.Fatalf("register: %v", err)
+		}
+	}
+	return tournament, rooms
+}
+
+func TestTournamentSeeding(t *testing.T) {
+	tournament, rooms := newTestTournament(t, TournamentConfig{Name: "cup", HeatSize: 2, Advance: 1}, 6)
+	if err := tournament.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	// 蛇形分組：種子 1,2,3 分入第 1,2,3 組，種子 4,5,6 分入第 3,2,1 組
+	want := [][]string{{"p1", "p6"}, {"p2", "p5"}, {"p3", "p4"}}
+	heats := tournament.Rounds[0].Heats
+	if len(heats) != len(want) || len(rooms) != len(want) {
+		t.Fatalf("got %d heats and %d rooms, want %d", len(heats), len(rooms), len(want))
+	}
+	for i, heat := range heats {
+		if !reflect.DeepEqual(heat.Players, want[i]) {
+			t.Fatalf("heat %d players = %v, want %v", i+1, heat.Players, want[i])
+		}
+		room := rooms[heat.RoomID]
+		if room == nil || len(room.AllowedNames) != 2 || !room.AllowedNames[want[i][0]] {
+			t.Fatalf("heat %d room does not admit its players: %+v", i+1, room)
+		}
+	}
+}
+
+func TestTournamentForfeitAndAdvance(t *testing.T) {
+	tournament, rooms := newTestTournament(t, TournamentConfig{Name: "cup", HeatSize: 2, Advance: 1}, 4)
+	if err := tournament.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	first, second := tournament.Rounds[0].Heats[0], tournament.Rounds[0].Heats[1]
+	firstRoom, secondRoom := rooms[first.RoomID], rooms[second.RoomID]
+
+	// 沒有玩家到場的分組賽以棄權結束，房間的計時器一併停止
+	fired := make(chan struct{})
+	firstRoom.mu.Lock()
+	firstRoom.timer = time.AfterFunc(10*time.Millisecond, func() { close(fired) })
+	firstRoom.mu.Unlock()
+	if err := tournament.StartHeat(1, 1, firstRoom); err != nil {
+		t.Fatalf("start heat: %v", err)
+	}
+	if first.Status != HeatStatusFinished || len(first.Results) != 0 {
+		t.Fatalf("forfeited heat = %+v, want finished without results", first)
+	}
+	if _, ok := rooms[first.RoomID]; ok {
+		t.Fatal("forfeited heat room was not removed")
+	}
+	select {
+	case <-fired:
+		t.Fatal("timer of the forfeited heat still fired")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// 另一組結束後只有一人晉級，直接成為冠軍
+	secondRoom.OnMatchEnd([]MatchStanding{
+		{Name: second.Players[1], TotalScore: 30, Rank: 1},
+		{Name: second.Players[0], TotalScore: 10, Rank: 2},
+	})
+	if !second.Results[0].Advanced || second.Results[1].Advanced {
+		t.Fatalf("results = %+v, want only the winner advanced", second.Results)
+	}
+	if tournament.Status != TournamentStatusFinished || tournament.Champion != second.Players[1] {
+		t.Fatalf("status %s champion %q, want finished with %s", tournament.Status, tournament.Champion, second.Players[1])
+	}
+}
@@ -0,0 +1,17 @@
+package game
+
+// Conn 玩家連線的傳輸層，讓房間與玩家邏輯不依賴特定傳輸方式（WebSocket、SSE、程序內連線等）
+type Conn interface {
+	// Codec 連線使用的消息編碼
+	Codec() Codec
+	// ReadMessage 阻塞讀取下一則客戶端消息，連線關閉後返回錯誤
+	ReadMessage() ([]byte, error)
+	// WriteMessage 發送一則已編碼的消息
+	WriteMessage(data []byte) error
+	// CloseWithCode 以關閉碼通知客戶端結束連線，讀取在客戶端回應或逾時後結束
+	CloseWithCode(code int, reason string)
+	// Close 立即關閉連線
+	Close() error
+	// RemoteAddr 客戶端位址，用於記錄；程序內的連線返回可辨識的名稱
+	RemoteAddr() string
+}
@@ -0,0 +1,161 @@
+package game
+
+import (
+	"compress/flate"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket 連線的預設參數
+const (
+	DefaultReadBufferSize   = 1024
+	DefaultWriteBufferSize  = 1024
+	DefaultMaxMessageSize   = 64 * 1024
+	DefaultCompressionLevel = flate.BestSpeed
+	DefaultWriteTimeout     = 2 * time.Second
+	// 發送關閉幀的逾時
+	closeWriteWait = time.Second
+)
+
+// WebSocketConfig WebSocket 連線的緩衝區、壓縮與消息大小限制
+type WebSocketConfig struct {
+	ReadBufferSize  int
+	WriteBufferSize int
+	// 是否啟用 permessage-deflate，需客戶端同時支援才會生效
+	EnableCompression bool
+	// 壓縮等級，範圍同 compress/flate（-2 至 9）
+	CompressionLevel int
+	// 單則客戶端消息的大小上限（位元組，以解壓縮後計算），超過時以 1009（CloseMessageTooBig）關閉連線
+	MaxMessageSize int64
+	// 單則伺服器消息的寫入逾時，房間在持有鎖時發送消息，逾時後關閉連線，避免停止讀取的客戶端阻塞整個房間
+	WriteTimeout time.Duration
+}
+
+// DefaultWebSocketConfig 返回預設的 WebSocket 連線參數
+func DefaultWebSocketConfig() WebSocketConfig {
+	return WebSocketConfig{
+		ReadBufferSize:    DefaultReadBufferSize,
+		WriteBufferSize:   DefaultWriteBufferSize,
+		EnableCompression: true,
+		CompressionLevel:  DefaultCompressionLevel,
+		MaxMessageSize:    DefaultMaxMessageSize,
+		WriteTimeout:      DefaultWriteTimeout,
+	}
+}
+
+// Validate 檢查連線參數是否合法
+func (c WebSocketConfig) Validate() error {
+	if c.ReadBufferSize <= 0 || c.WriteBufferSize <= 0 || c.MaxMessageSize <= 0 || c.WriteTimeout <= 0 {
+		return NewError(ErrCodeInvalidSettings)
+	}
+	if c.CompressionLevel < flate.HuffmanOnly || c.CompressionLevel > flate.BestCompression {
+		return NewError(ErrCodeInvalidSettings)
+	}
+	return nil
+}
+
+// 目前使用的連線參數
+var wsConfig = DefaultWebSocketConfig()
+
+var upgrader = newUpgrader(wsConfig)
+
+func newUpgrader(config WebSocketConfig) websocket.Upgrader {
+	return websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			return true
+		},
+		ReadBufferSize:    config.ReadBufferSize,
+		WriteBufferSize:   config.WriteBufferSize,
+		EnableCompression: config.EnableCompression,
+		Subprotocols:      Subprotocols(),
+	}
+}
+
+// ConfigureWebSocket 設定之後建立的 WebSocket 連線參數，需在伺服器開始接受連線前呼叫
+func ConfigureWebSocket(config WebSocketConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	wsConfig = config
+	upgrader = newUpgrader(config)
+	return nil
+}
+
+// 升級為 WebSocket 連線並套用消息大小限制與壓縮設定
+func upgradeConn(w http.ResponseWriter, r *http.Request) (Conn, error) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return nil, err
+	}
+	// 超過上限時 gorilla/websocket 會回覆 1009 關閉幀，讀取返回 websocket.ErrReadLimit
+	conn.SetReadLimit(wsConfig.MaxMessageSize)
+	if wsConfig.EnableCompression {
+		conn.EnableWriteCompression(true)
+		if err := conn.SetCompressionLevel(wsConfig.CompressionLevel); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return &wsConn{conn: conn, codec: CodecFor(conn.Subprotocol()), writeTimeout: wsConfig.WriteTimeout}, nil
+}
+
+// 讀取一則客戶端消息；SetReadLimit 只限制壓縮後的大小，因此解壓縮後再檢查一次，超過上限時以 1009 關閉連線
+func readMessage(conn *websocket.Conn) ([]byte, error) {
+	_, r, err := conn.NextReader()
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(r, wsConfig.MaxMessageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > wsConfig.MaxMessageSize {
+		closeMsg := websocket.FormatCloseMessage(websocket.CloseMessageTooBig, "message too large")
+		conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(closeWriteWait))
+		return nil, websocket.ErrReadLimit
+	}
+	return data, nil
+}
+
+// WebSocket 連線，以協商的子協定決定消息編碼
+type wsConn struct {
+	conn         *websocket.Conn
+	codec        Codec
+	writeTimeout time.Duration
+}
+
+func (c *wsConn) Codec() Codec { return c.codec }
+
+func (c *wsConn) ReadMessage() ([]byte, error) {
+	return readMessage(c.conn)
+}
+
+// 寫入逾時或失敗後連線已不可用，直接關閉使讀取迴圈結束，玩家隨之離開房間
+func (c *wsConn) WriteMessage(data []byte) error {
+	c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	if err := c.conn.WriteMessage(c.codec.FrameType(), data); err != nil {
+		c.conn.Close()
+		return err
+	}
+	return nil
+}
+
+// 發送關閉幀後繼續讀到客戶端回覆關閉或逾時為止，
+// 避免在仍有未讀資料時直接關閉造成連線重置，使客戶端收不到關閉碼
+func (c *wsConn) CloseWithCode(code int, reason string) {
+	deadline := time.Now().Add(closeWriteWait)
+	msg := websocket.FormatCloseMessage(code, reason)
+	c.conn.WriteControl(websocket.CloseMessage, msg, deadline)
+	c.conn.SetReadDeadline(deadline)
+}
+
+func (c *wsConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *wsConn) RemoteAddr() string {
+	return c.conn.RemoteAddr().String()
+}
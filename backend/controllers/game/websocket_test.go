@@ -0,0 +1,137 @@
+package game
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// 以目前的連線參數建立一條 WebSocket 連線，返回客戶端與伺服器端的連線
+func dialTestConn(t *testing.T, dialer *websocket.Dialer) (*websocket.Conn, Conn) {
+	t.Helper()
+	conns := make(chan Conn, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgradeConn(w, r)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		conns <- conn
+	}))
+	t.Cleanup(server.Close)
+
+	client, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client, <-conns
+}
+
+// 暫時替換連線參數，測試結束後還原
+func useWebSocketConfig(t *testing.T, config WebSocketConfig) {
+	t.Helper()
+	saved := wsConfig
+	if err := ConfigureWebSocket(config); err != nil {
+		t.Fatalf("configure: %v", err)
+	}
+	t.Cleanup(func() { ConfigureWebSocket(saved) })
+}
+
+func TestWebSocketConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*WebSocketConfig)
+		wantErr bool
+	}{
+		{name: "default", modify: func(*WebSocketConfig) {}},
+		{name: "no read buffer", modify: func(c *WebSocketConfig) { c.ReadBufferSize = 0 }, wantErr: true},
+		{name: "no message size", modify: func(c *WebSocketConfig) { c.MaxMessageSize = 0 }, wantErr: true},
+		{name: "compression level too high", modify: func(c *WebSocketConfig) { c.CompressionLevel = 10 }, wantErr: true},
+		{name: "huffman only", modify: func(c *WebSocketConfig) { c.CompressionLevel = -2 }},
+		{name: "no write timeout", modify: func(c *WebSocketConfig) { c.WriteTimeout = 0 }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultWebSocketConfig()
+			tt.modify(&config)
+			if err := config.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMessageSizeLimit(t *testing.T) {
+	config := DefaultWebSocketConfig()
+	config.MaxMessageSize = 1024
+	useWebSocketConfig(t, config)
+
+	tests := []struct {
+		name     string
+		compress bool
+	}{
+		{name: "uncompressed"},
+		// 壓縮後小於上限但解壓縮後超過上限的消息同樣拒絕
+		{name: "compressed", compress: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, conn := dialTestConn(t, &websocket.Dialer{EnableCompression: tt.compress})
+			client.EnableWriteCompression(tt.compress)
+
+			if err := client.WriteMessage(websocket.TextMessage, []byte(`{"type":"ready","payload":true}`)); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			if _, err := conn.ReadMessage(); err != nil {
+				t.Fatalf("small message: %v", err)
+			}
+
+			if err := client.WriteMessage(websocket.TextMessage, []byte(strings.Repeat(" ", 4096))); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			if _, err := conn.ReadMessage(); err != websocket.ErrReadLimit {
+				t.Fatalf("large message: err = %v, want %v", err, websocket.ErrReadLimit)
+			}
+			client.SetReadDeadline(time.Now().Add(time.Second))
+			_, _, err := client.ReadMessage()
+			if !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+				t.Fatalf("client got %v, want close %d", err, websocket.CloseMessageTooBig)
+			}
+		})
+	}
+}
+
+func TestWriteTimeoutClosesStalledConn(t *testing.T) {
+	config := DefaultWebSocketConfig()
+	config.WriteTimeout = 50 * time.Millisecond
+	useWebSocketConfig(t, config)
+
+	// 客戶端連線後不再讀取，伺服器的寫入最終會因緩衝區填滿而阻塞
+	_, conn := dialTestConn(t, websocket.DefaultDialer)
+
+	data := []byte(`"` + strings.Repeat("x", 64*1024) + `"`)
+	done := make(chan error, 1)
+	go func() {
+		for {
+			if err := conn.WriteMessage(data); err != nil {
+				done <- err
+				return
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("write to stalled client did not time out")
+	}
+
+	// 逾時後連線已關閉，之後的寫入立即失敗
+	if err := conn.WriteMessage([]byte(`{}`)); err == nil {
+		t.Fatal("write after timeout succeeded")
+	}
+}
@@ -2,8 +2,12 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/rejxcy/colorgame/backend/controllers/game"
 	"github.com/rejxcy/colorgame/backend/router"
 	"github.com/rejxcy/logger"
 )
@@ -18,6 +22,55 @@ func main() {
 	ginMode := "debug"
 	ginPort := 8080
 
+	// 每日挑戰換日的時區，預設為 Asia/Taipei
+	if tz := os.Getenv("DAILY_TIMEZONE"); tz != "" {
+		if err := game.GlobalDailyBoard.SetTimezone(tz); err != nil {
+			logger.Output.Error("Invalid DAILY_TIMEZONE %s: %v", tz, err)
+		}
+	}
+
+	// 作答後玩家列表更新的合併間隔（毫秒），0 表示每次作答立即廣播
+	if ms := os.Getenv("BROADCAST_INTERVAL_MS"); ms != "" {
+		if v, err := strconv.Atoi(ms); err == nil && v >= 0 {
+			game.BroadcastInterval = time.Duration(v) * time.Millisecond
+		} else {
+			logger.Output.Error("Invalid BROADCAST_INTERVAL_MS %s", ms)
+		}
+	}
+
+	// WebSocket 緩衝區、壓縮與消息大小限制
+	wsConfig := game.DefaultWebSocketConfig()
+	envInt("WS_READ_BUFFER_SIZE", &wsConfig.ReadBufferSize)
+	envInt("WS_WRITE_BUFFER_SIZE", &wsConfig.WriteBufferSize)
+	envInt("WS_COMPRESSION_LEVEL", &wsConfig.CompressionLevel)
+	if v := os.Getenv("WS_COMPRESSION"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			wsConfig.EnableCompression = enabled
+		} else {
+			logger.Output.Error("Invalid WS_COMPRESSION %s", v)
+		}
+	}
+	maxMessageSize := int(wsConfig.MaxMessageSize)
+	envInt("WS_MAX_MESSAGE_SIZE", &maxMessageSize)
+	wsConfig.MaxMessageSize = int64(maxMessageSize)
+	// 單則消息的寫入逾時（毫秒），逾時的連線會被關閉
+	writeTimeout := int(wsConfig.WriteTimeout / time.Millisecond)
+	envInt("WS_WRITE_TIMEOUT_MS", &writeTimeout)
+	wsConfig.WriteTimeout = time.Duration(writeTimeout) * time.Millisecond
+	if err := game.ConfigureWebSocket(wsConfig); err != nil {
+		logger.Output.Error("Invalid WebSocket config %+v: %v", wsConfig, err)
+	}
+
+	// 每條連線的速率限制（每秒消息數與可累積的數量），答案另有獨立限制
+	rateLimits := game.DefaultRateLimitConfig()
+	envRateLimit("RATE_LIMIT", &rateLimits.Connection)
+	answerLimit := rateLimits.Messages[game.MsgTypeAnswer]
+	envRateLimit("RATE_LIMIT_ANSWER", &answerLimit)
+	rateLimits.Messages[game.MsgTypeAnswer] = answerLimit
+	if err := game.ConfigureRateLimits(rateLimits); err != nil {
+		logger.Output.Error("Invalid rate limit config: %v", err)
+	}
+
 	r := gin.Default()
 	gin.SetMode(ginMode)
 	router.Routers(r)
@@ -25,3 +78,37 @@ func main() {
 	logger.Output.Info("Server running in %d", ginPort)
 	r.Run(fmt.Sprintf(":%d", ginPort))
 }
+
+// 讀取整數環境變數，未設定或格式錯誤時保留原本的值
+func envInt(name string, value *int) {
+	v := os.Getenv(name)
+	if v == "" {
+		return
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		logger.Output.Error("Invalid %s %s", name, v)
+		return
+	}
+	*value = n
+}
+
+// 讀取浮點數環境變數，未設定或格式錯誤時保留原本的值
+func envFloat(name string, value *float64) {
+	v := os.Getenv(name)
+	if v == "" {
+		return
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		logger.Output.Error("Invalid %s %s", name, v)
+		return
+	}
+	*value = f
+}
+
+// 讀取 <prefix>_RATE（可為小數）與 <prefix>_BURST 環境變數
+func envRateLimit(prefix string, limit *game.RateLimit) {
+	envFloat(prefix+"_RATE", &limit.Rate)
+	envInt(prefix+"_BURST", &limit.Burst)
+}
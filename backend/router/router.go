@@ -39,5 +39,24 @@ func Routers(engine *gin.Engine) {
 		c := game.New(ctx)
 		r := v1.Group("/game")
 		r.GET("/ws", c.HandleWebSocket)
+		r.GET("/sse", c.HandleSSE)
+		r.POST("/sse/:token", c.HandleSSESend)
+		r.GET("/solo", c.HandleSolo)
+		r.GET("/daily", c.HandleDaily)
+		r.GET("/daily/leaderboard", c.GetDailyLeaderboard)
+		r.GET("/challenge", c.HandleChallenge)
+		r.GET("/challenge/:code", c.GetChallenge)
+		r.GET("/quickplay", c.HandleQuickPlay)
+		r.POST("/rooms", c.CreateAutoHostRoom)
+		r.GET("/protocol", c.GetProtocolSchema)
+		r.GET("/stats/ratelimit", c.GetRateLimitStats)
+
+		t := v1.Group("/tournament")
+		t.POST("", c.CreateTournament)
+		t.GET("/:id", c.GetTournament)
+		t.POST("/:id/register", c.RegisterTournament)
+		t.POST("/:id/start", c.StartTournament)
+		t.POST("/:id/rounds/:round/heats/:heat/start", c.StartTournamentHeat)
+		t.GET("/:id/ws", c.HandleTournamentFeed)
 	}
 }
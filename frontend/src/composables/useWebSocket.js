@@ -2,6 +2,94 @@ import { ref } from 'vue'
 
 // 使用單例模式來保持連接狀態
 let wsInstance = null
+// 最後收到的房間事件序號與所屬房間，重新連線時用來補齊錯過的事件
+let lastSeq = 0
+// 最後處理的個人遊戲消息序號（遊戲狀態、分數變化）
+let lastPlayerSeq = 0
+let lastRoomId = null
+// 伺服器在 welcome 中發放的重新連線憑證，重新連線時帶回以恢復原本的玩家身分
+let sessionToken = null
+// 目前的玩家列表，用來套用伺服器送來的差異更新
+let playerList = []
+
+// 將差異更新套用到玩家列表，依排名排序，觀戰者（沒有排名）排在最後
+const applyPlayerListDelta = (list, delta) => {
+  const removed = new Set(delta.removed || [])
+  const players = new Map(list.filter(p => !removed.has(p.id)).map(p => [p.id, p]))
+  for (const change of delta.changed || []) {
+    players.set(change.id, { ...players.get(change.id), ...change })
+  }
+  return [...players.values()].sort((a, b) => (a.rank || Infinity) - (b.rank || Infinity))
+}
+
+// 伺服器位址，使用當前主機名
+const serverUrl = (protocol) => `${protocol}://${window.location.hostname}:8080/api/game`
+
+// 建立 WebSocket 連線，返回與 SSE 連線相同介面的物件
+const connectWebSocket = (params, handlers) => {
+  const url = `${serverUrl('ws')}/ws?${params}`
+  console.log('Connecting to WebSocket:', url)
+  const socket = new WebSocket(url)
+  socket.onopen = () => handlers.onopen()
+  socket.onerror = (error) => handlers.onerror(error)
+  socket.onclose = (event) => handlers.onclose(event.code, event.reason)
+  socket.onmessage = (event) => handlers.onmessage(event.data)
+  return {
+    transport: 'websocket',
+    send: (text) => socket.send(text),
+    close: () => socket.close()
+  }
+}
+
+// 建立 SSE 連線：以 EventSource 接收伺服器消息，以 POST 依序發送客戶端消息
+const connectSSE = (params, handlers) => {
+  const url = `${serverUrl('http')}/sse?${params}`
+  console.log('Connecting to SSE:', url)
+  const source = new EventSource(url)
+  let token = null
+  let closed = false
+  // 串接 POST 請求，確保伺服器依發送順序收到消息
+  let queue = Promise.resolve()
+
+  const close = (code, reason) => {
+    if (closed) return
+    closed = true
+    source.close()
+    handlers.onclose(code, reason)
+  }
+
+  source.addEventListener('session', (event) => {
+    token = JSON.parse(event.data).token
+    handlers.onopen()
+  })
+  source.addEventListener('close', (event) => {
+    const { code, reason } = JSON.parse(event.data)
+    close(code, reason)
+  })
+  source.onmessage = (event) => handlers.onmessage(event.data)
+  // EventSource 斷線後會自動重連並建立新的玩家，因此直接結束連線
+  source.onerror = (error) => {
+    if (token) {
+      close(1006, '')
+    } else {
+      source.close()
+      handlers.onerror(error)
+    }
+  }
+
+  return {
+    transport: 'sse',
+    send: (text) => {
+      queue = queue
+        .then(() => fetch(`${serverUrl('http')}/sse/${token}`, { method: 'POST', body: text }))
+        .then((res) => {
+          if (!res.ok) console.error('Failed to send message:', res.status)
+        })
+        .catch((err) => console.error('Failed to send message:', err))
+    },
+    close: () => close(1000, '')
+  }
+}
 
 export const useWebSocket = () => {
   const ws = ref(null)
@@ -14,48 +102,102 @@ export const useWebSocket = () => {
   }
 
   const connect = (roomId, playerName, isHost) => {
+    if (roomId !== lastRoomId) {
+      lastSeq = 0
+      lastPlayerSeq = 0
+      // 重新整理頁面後沿用同一分頁先前在此房間取得的憑證
+      sessionToken = sessionStorage.getItem(`session:${roomId}`)
+      lastRoomId = roomId
+      playerList = []
+    }
     return new Promise((resolve, reject) => {
       try {
-        // 使用當前主機名來建立連線
-        const wsUrl = `ws://${window.location.hostname}:8080/api/game/ws`
         const params = new URLSearchParams({
           room_id: roomId,
           player_name: playerName,
           is_host: isHost
         })
-
-        console.log('Connecting to WebSocket:', `${wsUrl}?${params}`)
-        ws.value = new WebSocket(`${wsUrl}?${params}`)
-
-        ws.value.onopen = () => {
-          console.log('WebSocket connected successfully')
-          isConnected.value = true
-          resolve()
-        }
-
-        ws.value.onerror = (error) => {
-          console.error('WebSocket connection error:', error)
-          isConnected.value = false
-          reject(new Error('WebSocket connection failed'))
+        if (sessionToken) {
+          params.set('session', sessionToken)
         }
+        let opened = false
 
-        ws.value.onclose = (event) => {
-          console.log('WebSocket disconnected:', event.code, event.reason)
-          isConnected.value = false
-          ws.value = null
-        }
-
-        ws.value.onmessage = (event) => {
-          try {
-            const data = JSON.parse(event.data)
-            console.log('Received message:', data)
-            messageHandlers.forEach(handler => handler(data))
-          } catch (err) {
-            console.error('Failed to parse message:', err)
+        const handlers = {
+          onopen: () => {
+            opened = true
+            isConnected.value = true
+            // 告知伺服器使用的協定版本與錯誤訊息語系
+            const locale = navigator.language.startsWith('zh') ? 'zh-TW' : 'en'
+            ws.value.send(JSON.stringify({ type: 'hello', payload: { version: 2, capabilities: ['player_list_delta'], locale } }))
+            // 重新連線時要求重送斷線期間的房間事件
+            if (lastSeq > 0) {
+              ws.value.send(JSON.stringify({ type: 'sync', payload: { since: lastSeq, playerSince: lastPlayerSeq } }))
+            }
+            resolve()
+          },
+          onerror: (error) => {
+            isConnected.value = false
+            if (opened) return
+            // WebSocket 被網路環境封鎖時改用 SSE 連線
+            if (ws.value && ws.value.transport === 'websocket') {
+              console.warn('WebSocket unavailable, falling back to SSE:', error)
+              ws.value = connectSSE(params, handlers)
+              return
+            }
+            reject(new Error('Connection failed'))
+          },
+          onclose: (code, reason) => {
+            // 未建立成功的連線由 onerror 處理（可能已改用 SSE）
+            if (!opened) return
+            console.log('Connection closed:', code, reason)
+            isConnected.value = false
+            ws.value = null
+          },
+          onmessage: (text) => {
+            try {
+              let data = JSON.parse(text)
+              console.log('Received message:', data)
+              if (data.type === 'welcome' && data.payload.sessionToken) {
+                sessionToken = data.payload.sessionToken
+                sessionStorage.setItem(`session:${roomId}`, sessionToken)
+              }
+              if (data.type === 'snapshot') {
+                lastSeq = data.payload.seq
+                lastPlayerSeq = data.payload.playerSeq
+              } else if (data.seq) {
+                // 已處理過的事件不重複處理；序號不連續時改要求完整快照
+                if (data.seq <= lastSeq) return
+                if (lastSeq > 0 && data.seq > lastSeq + 1) {
+                  ws.value.send(JSON.stringify({ type: 'sync', payload: {} }))
+                }
+                lastSeq = data.seq
+              } else if (data.pseq) {
+                // 個人遊戲消息同樣依序號去重與檢查缺漏
+                if (data.pseq <= lastPlayerSeq) return
+                if (lastPlayerSeq > 0 && data.pseq > lastPlayerSeq + 1) {
+                  ws.value.send(JSON.stringify({ type: 'sync', payload: {} }))
+                }
+                lastPlayerSeq = data.pseq
+              }
+              // 差異更新轉為完整的玩家列表再交給各頁面處理
+              if (data.type === 'player_list') {
+                playerList = data.payload
+              } else if (data.type === 'snapshot') {
+                playerList = data.payload.players
+              } else if (data.type === 'player_list_delta') {
+                playerList = applyPlayerListDelta(playerList, data.payload)
+                data = { ...data, type: 'player_list', payload: playerList }
+              }
+              messageHandlers.forEach(handler => handler(data))
+            } catch (err) {
+              console.error('Failed to parse message:', err)
+            }
           }
         }
+
+        ws.value = connectWebSocket(params, handlers)
       } catch (err) {
-        console.error('Failed to create WebSocket:', err)
+        console.error('Failed to connect:', err)
         isConnected.value = false
         reject(err)
       }
@@ -63,7 +205,7 @@ export const useWebSocket = () => {
   }
 
   const send = (message) => {
-    if (!ws.value || ws.value.readyState !== WebSocket.OPEN) {
+    if (!ws.value || !isConnected.value) {
       console.error('WebSocket is not connected, attempting to reconnect...')
       // 可以在這裡添加重連邏輯
       return
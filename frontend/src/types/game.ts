@@ -6,6 +6,8 @@ export interface GameState {
   percentage: number;
   wrongCount: number;
   isFinished: boolean;
+  mode: 'classic' | 'timed';
+  // 計時模式沒有固定題數，totalQuiz 為 0
   totalQuiz: number;
 }
 
@@ -25,26 +27,39 @@ export interface Player {
   name: string;
   isHost: boolean;
   isReady: boolean;
+  isBot?: boolean;
   score: number;
   game: GameState;
 }
 
-// WebSocket 消息類型
+// WebSocket 協定版本，連線後以 hello 告知伺服器
+export const PROTOCOL_VERSION = 2
+
+// WebSocket 消息類型（與後端 protocol.schema.json 一致）
 export enum MessageType {
+  Hello = 'hello',
+  Welcome = 'welcome',
+  Ack = 'ack',
+  Sync = 'sync',
+  Snapshot = 'snapshot',
+  PlayerListDelta = 'player_list_delta',
   Answer = 'answer',
-  Restart = 'restart',
+  GameReset = 'game_reset',
   GameState = 'game_state',
-  GameOver = 'game_over',
+  GameEnd = 'game_end',
   Error = 'error',
-  JoinRoom = 'join_room',
-  LeaveRoom = 'leave_room',
   PlayerList = 'player_list',
   GameStart = 'game_start',
-  GameRank = 'game_rank',
-  Progress = 'progress',
-  Ready = 'ready'
+  MatchEnd = 'match_end',
+  Ready = 'ready',
+  AddBots = 'add_bots',
+  RemoveBots = 'remove_bots'
 }
 
+// 機器人難度
+export type BotDifficulty = 'easy' | 'medium' | 'hard'
+
+
 // WebSocket 消息
 export interface WSMessage {
   type: MessageType;